@@ -0,0 +1,286 @@
+// Package extracheck defines an analyzer that reports calls which hand types
+// carrying partialmarshal.Extra to encoding/json.
+//
+// encoding/json does not know about Extra: json.Marshal writes it as a nested
+// "Extra" object and json.Unmarshal drops the unknown keys it is meant to
+// keep. The analyzer reports such values passed to json.Marshal,
+// json.MarshalIndent, json.Unmarshal, (*json.Encoder).Encode and
+// (*json.Decoder).Decode, and to the functions of the analyzed package that
+// forward one of their parameters to them. The extra payload is recognized
+// the way partialmarshal recognizes it: an embedded Extra or ExtraOf, or a
+// field of one of these types tagged `partialmarshal:",extra"`. Types that
+// implement json.Marshaler or json.Unmarshaler are not reported, since
+// encoding/json leaves them to their own methods.
+package extracheck
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// partialmarshalPath is the import path of the partialmarshal package.
+const partialmarshalPath = "github.com/mrhwick/partialmarshal"
+
+// Analyzer reports types carrying partialmarshal.Extra passed to encoding/json.
+var Analyzer = &analysis.Analyzer{
+	Name: "extracheck",
+	Doc:  "report types carrying partialmarshal.Extra that are passed to encoding/json",
+	Run:  run,
+}
+
+// direction tells whether a sink encodes or decodes its argument.
+type direction int
+
+const (
+	encodes direction = iota
+	decodes
+)
+
+// sink is a function that hands one of its arguments to encoding/json.
+type sink struct {
+	name      string // name of the function as reported
+	argument  int    // index of the argument handed to encoding/json
+	direction direction
+}
+
+// jsonSinks are the encoding/json functions and methods, keyed by their
+// receiver type name and function name.
+var jsonSinks = map[string]sink{
+	"Marshal":        {"json.Marshal", 0, encodes},
+	"MarshalIndent":  {"json.MarshalIndent", 0, encodes},
+	"Unmarshal":      {"json.Unmarshal", 1, decodes},
+	"Encoder.Encode": {"(*json.Encoder).Encode", 0, encodes},
+	"Decoder.Decode": {"(*json.Decoder).Decode", 0, decodes},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// 1. Find the functions of the package that forward a parameter to a sink
+	wrappers := findWrappers(pass)
+
+	// 2. Report the calls that hand a type carrying Extra to a sink
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			s, ok := sinkOf(pass, call, wrappers)
+			if !ok || s.argument >= len(call.Args) {
+				return true
+			}
+			argumentType := pass.TypesInfo.TypeOf(call.Args[s.argument])
+			if argumentType == nil {
+				return true
+			}
+			path, found := extraPath(argumentType, s.direction, map[types.Type]bool{})
+			if !found {
+				return true
+			}
+			carrier := types.TypeString(argumentType, types.RelativeTo(pass.Pkg)) + path
+			if s.direction == encodes {
+				pass.Reportf(call.Pos(), "%s writes the partialmarshal.Extra of %s as a nested object; use partialmarshal.Marshal", s.name, carrier)
+			} else {
+				pass.Reportf(call.Pos(), "%s drops the unknown keys kept by the partialmarshal.Extra of %s; use partialmarshal.Unmarshal", s.name, carrier)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// callee returns the function or method called by call, if it is static.
+func callee(pass *analysis.Pass, call *ast.CallExpr) (*types.Func, bool) {
+	var obj types.Object
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		obj = pass.TypesInfo.Uses[fun]
+	case *ast.SelectorExpr:
+		if selection, ok := pass.TypesInfo.Selections[fun]; ok {
+			obj = selection.Obj()
+		} else {
+			obj = pass.TypesInfo.Uses[fun.Sel]
+		}
+	}
+	function, ok := obj.(*types.Func)
+	return function, ok
+}
+
+// sinkOf returns the sink that call hands an argument to, if any.
+func sinkOf(pass *analysis.Pass, call *ast.CallExpr, wrappers map[*types.Func]sink) (sink, bool) {
+	function, ok := callee(pass, call)
+	if !ok {
+		return sink{}, false
+	}
+	if s, ok := wrappers[function]; ok {
+		return s, true
+	}
+	if function.Pkg() == nil || function.Pkg().Path() != "encoding/json" {
+		return sink{}, false
+	}
+	key := function.Name()
+	if recv := function.Type().(*types.Signature).Recv(); recv != nil {
+		named, ok := types.Unalias(derefType(recv.Type())).(*types.Named)
+		if !ok {
+			return sink{}, false
+		}
+		key = named.Obj().Name() + "." + key
+	}
+	s, ok := jsonSinks[key]
+	return s, ok
+}
+
+// findWrappers returns the functions of the package that pass one of their
+// parameters of interface type on to a sink, directly or through other
+// wrappers.
+func findWrappers(pass *analysis.Pass) map[*types.Func]sink {
+	wrappers := map[*types.Func]sink{}
+	for changed := true; changed; {
+		changed = false
+		for _, file := range pass.Files {
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok || funcDecl.Body == nil {
+					continue
+				}
+				function, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+				if _, found := wrappers[function]; !ok || found {
+					continue
+				}
+				if s, ok := forwardedParameter(pass, funcDecl, function, wrappers); ok {
+					wrappers[function] = s
+					changed = true
+				}
+			}
+		}
+	}
+	return wrappers
+}
+
+// forwardedParameter returns the sink that function forwards one of its
+// parameters to, if any.
+func forwardedParameter(pass *analysis.Pass, funcDecl *ast.FuncDecl, function *types.Func, wrappers map[*types.Func]sink) (sink, bool) {
+	params := function.Type().(*types.Signature).Params()
+	var result sink
+	found := false
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || found {
+			return !found
+		}
+		s, ok := sinkOf(pass, call, wrappers)
+		if !ok || s.argument >= len(call.Args) {
+			return true
+		}
+		ident, ok := ast.Unparen(call.Args[s.argument]).(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for i := 0; i < params.Len(); i++ {
+			if params.At(i) == pass.TypesInfo.Uses[ident] && types.IsInterface(params.At(i).Type()) {
+				result = sink{function.Name(), i, s.direction}
+				found = true
+			}
+		}
+		return true
+	})
+	return result, found
+}
+
+// extraPath reports whether encoding/json reaches a struct storing its extra
+// payload in a partialmarshal.Extra or ExtraOf when handling a value of type
+// t, and returns the field path from t to that struct.
+func extraPath(t types.Type, d direction, seen map[types.Type]bool) (string, bool) {
+	t = derefType(t)
+	if seen[t] || handlesJSON(t, d) {
+		return "", false
+	}
+	seen[t] = true
+
+	switch underlying := t.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < underlying.NumFields(); i++ {
+			if isExtraField(underlying.Field(i), underlying.Tag(i)) {
+				return "", true
+			}
+		}
+		for i := 0; i < underlying.NumFields(); i++ {
+			field := underlying.Field(i)
+			name, _, _ := strings.Cut(reflect.StructTag(underlying.Tag(i)).Get("json"), ",")
+			if !field.Exported() || name == "-" {
+				continue
+			}
+			if path, found := extraPath(field.Type(), d, seen); found {
+				return "." + field.Name() + path, true
+			}
+		}
+	case *types.Slice:
+		return extraPath(underlying.Elem(), d, seen)
+	case *types.Array:
+		return extraPath(underlying.Elem(), d, seen)
+	case *types.Map:
+		return extraPath(underlying.Elem(), d, seen)
+	}
+	return "", false
+}
+
+// isExtraField reports whether field, with the struct tag tag, stores the
+// extra payload of its struct: an embedded partialmarshal.Extra or ExtraOf,
+// or a field of one of these types tagged `partialmarshal:",extra"`.
+func isExtraField(field *types.Var, tag string) bool {
+	if !isPartialmarshal(field.Type(), "Extra") && !isPartialmarshal(field.Type(), "ExtraOf") {
+		return false
+	}
+	if field.Embedded() {
+		return true
+	}
+	options := strings.Split(reflect.StructTag(tag).Get("partialmarshal"), ",")
+	for _, option := range options[1:] {
+		if option == "extra" {
+			return true
+		}
+	}
+	return false
+}
+
+// handlesJSON reports whether values of type t encode or decode themselves.
+func handlesJSON(t types.Type, d direction) bool {
+	if d == encodes {
+		return hasMethod(t, "MarshalJSON") || hasMethod(types.NewPointer(t), "MarshalJSON") || hasMethod(types.NewPointer(t), "MarshalText")
+	}
+	return hasMethod(types.NewPointer(t), "UnmarshalJSON") || hasMethod(types.NewPointer(t), "UnmarshalText")
+}
+
+// hasMethod reports whether the method set of t contains the named method.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(t).Lookup(nil, name) != nil
+}
+
+// derefType returns the type that t points to, or t itself.
+func derefType(t types.Type) types.Type {
+	for {
+		pointer, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			return t
+		}
+		t = pointer.Elem()
+	}
+}
+
+// isPartialmarshal reports whether t is the partialmarshal type of the given
+// name, or an instance of it for generic types.
+func isPartialmarshal(t types.Type, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Origin().Obj()
+	if obj.Name() != name || obj.Pkg() == nil {
+		return false
+	}
+	path := obj.Pkg().Path()
+	return path == partialmarshalPath || strings.HasSuffix(path, "/vendor/"+partialmarshalPath)
+}
@@ -0,0 +1,80 @@
+package extracheck
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/analysis"
+)
+
+// wantPattern matches the expectations written next to the calls of the
+// test packages, like the analysistest package of golang.org/x/tools does.
+var wantPattern = regexp.MustCompile("// want `(.*)`")
+
+func TestAnalyzer(t *testing.T) {
+	fset := token.NewFileSet()
+	filenames, err := filepath.Glob(filepath.Join("testdata", "src", "a", "*.go"))
+	assert.NoError(t, err)
+
+	// 1. Load the test package and its expectations by line
+	var files []*ast.File
+	wants := map[int]*regexp.Regexp{}
+	for _, filename := range filenames {
+		file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		assert.NoError(t, err)
+		files = append(files, file)
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				if match := wantPattern.FindStringSubmatch(comment.Text); match != nil {
+					wants[fset.Position(comment.Pos()).Line] = regexp.MustCompile(match[1])
+				}
+			}
+		}
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := config.Check("a", fset, files, info)
+	assert.NoError(t, err)
+
+	// 2. Run the analyzer and match its diagnostics with the expectations
+	assert.NoError(t, analysis.Validate([]*analysis.Analyzer{Analyzer}))
+	var diagnostics []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report:    func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d) },
+	}
+	_, err = Analyzer.Run(pass)
+	assert.NoError(t, err)
+
+	for _, diagnostic := range diagnostics {
+		line := fset.Position(diagnostic.Pos).Line
+		want, found := wants[line]
+		if !found {
+			t.Errorf("unexpected diagnostic on line %d: %s", line, diagnostic.Message)
+			continue
+		}
+		assert.Regexp(t, want, diagnostic.Message, "line "+strconv.Itoa(line))
+		delete(wants, line)
+	}
+	for line, want := range wants {
+		t.Errorf("no diagnostic on line %d matching %s", line, strings.TrimSpace(want.String()))
+	}
+}
@@ -0,0 +1,88 @@
+package a
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/mrhwick/partialmarshal"
+)
+
+type Person struct {
+	Name string `json:"name"`
+	partialmarshal.Extra
+}
+
+type Metrics struct {
+	Host string `json:"host"`
+	partialmarshal.ExtraOf[float64]
+}
+
+type Tagged struct {
+	Name string               `json:"name"`
+	Rest partialmarshal.Extra `partialmarshal:",extra"`
+}
+
+type Named struct {
+	Name  string               `json:"name"`
+	Other partialmarshal.Extra `json:"other"`
+}
+
+type Envelope struct {
+	Status string            `json:"status"`
+	People map[string]Person `json:"people"`
+}
+
+type Hidden struct {
+	Person Person `json:"-"`
+	person Person
+}
+
+type Generated struct {
+	Name string `json:"name"`
+	partialmarshal.Extra
+}
+
+func (g Generated) MarshalJSON() ([]byte, error) { return partialmarshal.Marshal(g) }
+
+func (g *Generated) UnmarshalJSON(data []byte) error { return partialmarshal.Unmarshal(data, g) }
+
+type Plain struct {
+	Name string `json:"name"`
+}
+
+func write(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func writeStdout(v interface{}) error {
+	return write(os.Stdout, v)
+}
+
+func calls(data []byte, p Person, people []*Person) {
+	json.Marshal(p)                      // want `json\.Marshal writes the partialmarshal\.Extra of Person as a nested object; use partialmarshal\.Marshal`
+	json.MarshalIndent(&p, "", "  ")     // want `json\.MarshalIndent writes the partialmarshal\.Extra of \*Person`
+	json.Marshal(people)                 // want `json\.Marshal writes the partialmarshal\.Extra of \[\]\*Person`
+	json.Unmarshal(data, &p)             // want `json\.Unmarshal drops the unknown keys kept by the partialmarshal\.Extra of \*Person; use partialmarshal\.Unmarshal`
+	json.Unmarshal(data, &Metrics{})     // want `json\.Unmarshal drops the unknown keys kept by the partialmarshal\.Extra of \*Metrics`
+	json.Marshal(Tagged{})               // want `json\.Marshal writes the partialmarshal\.Extra of Tagged as a nested object`
+	json.Unmarshal(data, &Tagged{})      // want `json\.Unmarshal drops the unknown keys kept by the partialmarshal\.Extra of \*Tagged`
+	json.Marshal(Envelope{})             // want `json\.Marshal writes the partialmarshal\.Extra of Envelope\.People as a nested object`
+	json.NewEncoder(os.Stdout).Encode(p) // want `\(\*json\.Encoder\)\.Encode writes the partialmarshal\.Extra of Person`
+	json.NewDecoder(os.Stdin).Decode(&p) // want `\(\*json\.Decoder\)\.Decode drops the unknown keys`
+	write(os.Stdout, p)                  // want `write writes the partialmarshal\.Extra of Person`
+	writeStdout(&p)                      // want `writeStdout writes the partialmarshal\.Extra of \*Person`
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.Encode([]Envelope{}) // want `\(\*json\.Encoder\)\.Encode writes the partialmarshal\.Extra of \[\]Envelope\.People`
+
+	json.Marshal(Hidden{})
+	json.Marshal(Named{})
+	json.Marshal(Generated{})
+	json.Unmarshal(data, &Generated{})
+	json.Marshal(Plain{})
+	json.Marshal(partialmarshal.Document[Plain]{})
+	json.Marshal(map[string]interface{}{"person": p})
+	partialmarshal.Marshal(p)
+	partialmarshal.Unmarshal(data, &p)
+	write(os.Stdout, Plain{})
+}
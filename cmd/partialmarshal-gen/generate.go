@@ -0,0 +1,535 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// partialmarshalPath is the import path of the partialmarshal package.
+const partialmarshalPath = "github.com/mrhwick/partialmarshal"
+
+// generate parses the package in dir and returns the source of a file that
+// declares MarshalJSON and UnmarshalJSON for each of its struct types that
+// embed partialmarshal.Extra. The output file itself is left out of the
+// package, so that it can be generated again.
+func generate(dir, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	sourceImporter := importer.ForCompiler(fset, "source", nil)
+
+	// 1. Type-check the package and find the struct types that embed Extra
+	pkg, err := loadPackage(fset, sourceImporter, dir, output, nil)
+	if err != nil {
+		return nil, err
+	}
+	names, err := extraStructs(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no struct type embeds partialmarshal.Extra in %s", dir)
+	}
+
+	// 2. Type-check it again with stub methods, so that the method sets of
+	// its types include the methods about to be generated
+	pkg, err = loadPackage(fset, sourceImporter, dir, output, stubMethods(pkg.Name(), names))
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Write the methods of every struct type
+	g := &generator{pkg: pkg, imports: map[string]string{"json": "encoding/json"}}
+	for _, name := range names {
+		err = g.writeMethods(pkg.Scope().Lookup(name).Type().(*types.Named))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g.source()
+}
+
+// loadPackage parses and type-checks the package in dir without the output
+// file, adding the extra source when it is not nil.
+func loadPackage(fset *token.FileSet, sourceImporter types.Importer, dir, output string, extra []byte) (*types.Package, error) {
+	buildPackage, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, name := range buildPackage.GoFiles {
+		if name == filepath.Base(output) {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if extra != nil {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, filepath.Base(output)), extra, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	config := types.Config{Importer: sourceImporter}
+	return config.Check(buildPackage.ImportPath, fset, files, nil)
+}
+
+// extraStructs returns the names of the struct types of pkg that embed
+// partialmarshal.Extra, in alphabetical order.
+func extraStructs(pkg *types.Package) ([]string, error) {
+	var names []string
+	for _, name := range pkg.Scope().Names() {
+		typeName, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+		named, ok := typeName.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			// Generic types are left to the reflection path
+			continue
+		}
+		structType, ok := named.Underlying().(*types.Struct)
+		if !ok || !embeds(structType, "Extra") {
+			continue
+		}
+		for i := 0; i < named.NumMethods(); i++ {
+			if method := named.Method(i).Name(); method == "MarshalJSON" || method == "UnmarshalJSON" {
+				return nil, fmt.Errorf("%s already declares %s", name, method)
+			}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// stubMethods returns the source of a file that declares empty MarshalJSON
+// and UnmarshalJSON methods for the named types.
+func stubMethods(pkgName string, names []string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n", pkgName)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "func (%s) MarshalJSON() ([]byte, error) { return nil, nil }\n", name)
+		fmt.Fprintf(&buf, "func (*%s) UnmarshalJSON([]byte) error { return nil }\n", name)
+	}
+	return buf.Bytes()
+}
+
+// isPartialmarshal reports whether t is the partialmarshal type of the given
+// name, or an instance of it for generic types.
+func isPartialmarshal(t types.Type, name string) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Origin().Obj()
+	if obj.Name() != name || obj.Pkg() == nil {
+		return false
+	}
+	path := obj.Pkg().Path()
+	return path == partialmarshalPath || strings.HasSuffix(path, "/vendor/"+partialmarshalPath)
+}
+
+// embeds reports whether structType embeds the partialmarshal type of the
+// given name.
+func embeds(structType *types.Struct, name string) bool {
+	for i := 0; i < structType.NumFields(); i++ {
+		if field := structType.Field(i); field.Embedded() && isPartialmarshal(field.Type(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// carriesExtra reports whether partialmarshal.Marshal encodes structType
+// itself rather than leaving it to encoding/json.
+func carriesExtra(structType *types.Struct) bool {
+	if embeds(structType, "Extra") || embeds(structType, "ExtraOf") || embeds(structType, "Presence") {
+		return true
+	}
+	for i := 0; i < structType.NumFields(); i++ {
+		if isOptional(structType.Field(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOptional reports whether t is a partialmarshal.Optional or a pointer to one.
+func isOptional(t types.Type) bool {
+	if pointer, ok := t.Underlying().(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	return isPartialmarshal(t, "Optional")
+}
+
+// hasMethod reports whether the method set of t contains the named method.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(t).Lookup(nil, name) != nil
+}
+
+// structPointer returns the struct type that t points to, if any.
+func structPointer(t types.Type) (*types.Struct, bool) {
+	pointer, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+	structType, ok := pointer.Elem().Underlying().(*types.Struct)
+	return structType, ok
+}
+
+// isStructSlice reports whether t is a slice of structs or of pointers to
+// structs, which partialmarshal.Marshal encodes element by element.
+func isStructSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	if _, ok := slice.Elem().Underlying().(*types.Struct); ok {
+		return true
+	}
+	_, ok = structPointer(slice.Elem())
+	return ok
+}
+
+// decodesWithJSON reports whether values of type t are decoded by
+// encoding/json on the reflection path, rather than by partialmarshal.Unmarshal.
+func decodesWithJSON(t types.Type) bool {
+	if hasMethod(types.NewPointer(t), "UnmarshalJSON") {
+		return true
+	}
+	switch underlying := t.Underlying().(type) {
+	case *types.Struct:
+		return false
+	case *types.Slice:
+		return decodesWithJSON(underlying.Elem())
+	}
+	return true
+}
+
+// encodesWithJSON reports whether encoding/json writes values of type t the
+// same way partialmarshal.Marshal does.
+func encodesWithJSON(t types.Type) bool {
+	if hasMethod(t, "MarshalJSON") {
+		return true
+	}
+	if structType, ok := t.Underlying().(*types.Struct); ok {
+		return !carriesExtra(structType)
+	}
+	if structType, ok := structPointer(t); ok {
+		return !carriesExtra(structType)
+	}
+	if isStructSlice(t) {
+		return encodesWithJSON(t.Underlying().(*types.Slice).Elem())
+	}
+	return true
+}
+
+// field describes how a struct field is matched and written.
+type field struct {
+	name     string   // Go name of the field
+	jsonName string   // key that the field is written under
+	keys     []string // keys that match the field, in the order they are tried
+	options  []string // options of the json tag
+	omitted  bool     // whether the json tag is "-"
+	fieldVar *types.Var
+}
+
+func newField(fieldVar *types.Var, tag string) field {
+	jsonTag := reflect.StructTag(tag).Get("json")
+	parts := strings.Split(jsonTag, ",")
+	f := field{
+		name:     fieldVar.Name(),
+		jsonName: parts[0],
+		keys:     []string{fieldVar.Name()},
+		options:  parts[1:],
+		omitted:  jsonTag == "-",
+		fieldVar: fieldVar,
+	}
+	if f.jsonName == "" {
+		f.jsonName = f.name
+	}
+	for _, key := range parts {
+		if key != "" {
+			f.keys = append(f.keys, key)
+		}
+	}
+	return f
+}
+
+func (f field) hasOption(option string) bool {
+	for _, o := range f.options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// generator writes the methods of the struct types of one package.
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // import paths by package name
+	body    bytes.Buffer
+}
+
+// qualifier returns the name that pkg is referred to by in the generated
+// file, importing it when needed.
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	name := pkg.Name()
+	for suffix := 2; ; suffix++ {
+		path, found := g.imports[name]
+		if !found || path == pkg.Path() {
+			break
+		}
+		name = pkg.Name() + strconv.Itoa(suffix)
+	}
+	g.imports[name] = pkg.Path()
+	return name
+}
+
+// typeString returns t as written in the generated file.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// partialmarshal returns the qualified name of the named partialmarshal
+// identifier, given the struct type that embeds partialmarshal.Extra.
+func (g *generator) partialmarshal(structType *types.Struct, name string) string {
+	for i := 0; i < structType.NumFields(); i++ {
+		if field := structType.Field(i); field.Embedded() && isPartialmarshal(field.Type(), "Extra") {
+			if qualifier := g.qualifier(types.Unalias(field.Type()).(*types.Named).Obj().Pkg()); qualifier != "" {
+				return qualifier + "." + name
+			}
+		}
+	}
+	return name
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// writeMethods writes the MarshalJSON and UnmarshalJSON methods of named.
+func (g *generator) writeMethods(named *types.Named) error {
+	structType := named.Underlying().(*types.Struct)
+	name := named.Obj().Name()
+
+	// 1. Collect the exported fields, leaving out the extra storage
+	hasPresence := embeds(structType, "Presence")
+	var fields []field
+	for i := 0; i < structType.NumFields(); i++ {
+		fieldVar := structType.Field(i)
+		if !fieldVar.Exported() {
+			continue
+		}
+		if fieldVar.Embedded() && (isPartialmarshal(fieldVar.Type(), "Extra") || isPartialmarshal(fieldVar.Type(), "ExtraOf") || isPartialmarshal(fieldVar.Type(), "Presence")) {
+			continue
+		}
+		if _, found := reflect.StructTag(structType.Tag(i)).Lookup("partialmarshal"); found {
+			return fmt.Errorf("%s.%s: the partialmarshal tag is not supported", name, fieldVar.Name())
+		}
+		fields = append(fields, newField(fieldVar, structType.Tag(i)))
+	}
+
+	// 2. Write the methods
+	err := g.writeMarshal(name, structType, fields, hasPresence)
+	if err != nil {
+		return err
+	}
+	g.writeUnmarshal(name, structType, fields, hasPresence)
+	return nil
+}
+
+func (g *generator) writeMarshal(name string, structType *types.Struct, fields []field, hasPresence bool) error {
+	g.printf("// MarshalJSON returns the JSON encoding of v, placing its extra payload into\n")
+	g.printf("// the JSON output as top-level key/value pairs like partialmarshal.Marshal.\n")
+	g.printf("func (v %s) MarshalJSON() ([]byte, error) {\n", name)
+	g.printf("object := make(map[string]interface{}, %d+len(v.Extra))\n", len(fields))
+	for _, f := range fields {
+		if f.omitted {
+			continue
+		}
+		fieldType := f.fieldVar.Type()
+		value := "v." + f.name
+		var conditions []string
+		if hasPresence {
+			conditions = append(conditions, fmt.Sprintf("(v.Presence == nil || v.Presence.IsSet(%q))", f.jsonName))
+		}
+
+		_, isStruct := fieldType.Underlying().(*types.Struct)
+		_, isPointer := structPointer(fieldType)
+		switch {
+		case isOptional(fieldType):
+			if isPointer {
+				conditions = append(conditions, fmt.Sprintf("(%s == nil || %s.Set)", value, value))
+			} else {
+				conditions = append(conditions, value+".Set")
+			}
+		case isStruct:
+			// Substructs are written even when their tag has omitempty
+		case isPointer || isStructSlice(fieldType):
+			if f.hasOption("omitempty") {
+				conditions = append(conditions, value+" != nil")
+			}
+		default:
+			if f.hasOption("omitempty") {
+				condition, err := g.nonZero(value, fieldType)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %s", name, f.name, err)
+				}
+				conditions = append(conditions, condition)
+			}
+			if f.hasOption("string") {
+				if !hasMethod(fieldType, "String") {
+					// Fields with the string option that are not fmt.Stringers are left out
+					continue
+				}
+				value += ".String()"
+			}
+		}
+
+		if len(conditions) == 0 && encodesWithJSON(fieldType) {
+			g.printf("object[%q] = %s\n", f.jsonName, value)
+			continue
+		}
+		if len(conditions) > 0 {
+			g.printf("if %s {\n", strings.Join(conditions, " && "))
+		} else {
+			g.printf("{\n")
+		}
+		if encodesWithJSON(fieldType) {
+			g.printf("object[%q] = %s\n", f.jsonName, value)
+		} else {
+			g.printf("encoded, err := %s(%s)\n", g.partialmarshal(structType, "Marshal"), value)
+			g.printf("if err != nil {\nreturn nil, err\n}\n")
+			g.printf("object[%q] = json.RawMessage(encoded)\n", f.jsonName)
+		}
+		g.printf("}\n")
+	}
+	g.printf("for key, value := range v.Extra {\nobject[key] = value\n}\n")
+	g.printf("return json.Marshal(object)\n")
+	g.printf("}\n\n")
+	return nil
+}
+
+// nonZero returns the condition under which value of type t is not the zero
+// value, which omitempty fields are left out for.
+func (g *generator) nonZero(value string, t types.Type) (string, error) {
+	switch underlying := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case underlying.Info()&types.IsBoolean != 0:
+			return value, nil
+		case underlying.Info()&types.IsString != 0:
+			return value + ` != ""`, nil
+		case underlying.Info()&types.IsNumeric != 0:
+			return value + " != 0", nil
+		case underlying.Kind() == types.UnsafePointer:
+			return value + " != nil", nil
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Signature, *types.Chan, *types.Interface:
+		return value + " != nil", nil
+	case *types.Array:
+		if types.Comparable(t) {
+			return fmt.Sprintf("%s != (%s{})", value, g.typeString(t)), nil
+		}
+	}
+	return "", fmt.Errorf("omitempty is not supported for type %s", t)
+}
+
+func (g *generator) writeUnmarshal(name string, structType *types.Struct, fields []field, hasPresence bool) {
+	g.printf("// UnmarshalJSON parses the JSON-encoded data into v, placing any unmatching\n")
+	g.printf("// data into its embedded Extra like partialmarshal.Unmarshal.\n")
+	g.printf("func (v *%s) UnmarshalJSON(data []byte) error {\n", name)
+	g.printf("var rawMap map[string]json.RawMessage\n")
+	g.printf("if err := json.Unmarshal(data, &rawMap); err != nil {\nreturn err\n}\n")
+	g.printf("if rawMap == nil {\n// A JSON null leaves v unchanged\nreturn nil\n}\n")
+	if hasPresence {
+		g.printf("v.Presence = %s{}\n", g.partialmarshal(structType, "Presence"))
+	}
+	for _, f := range fields {
+		fieldType := f.fieldVar.Type()
+		keys := make([]string, 0, len(f.keys))
+		for _, key := range f.keys {
+			keys = append(keys, strconv.Quote(key))
+		}
+		g.printf("for _, key := range []string{%s} {\n", strings.Join(keys, ", "))
+		g.printf("rawValue, found := rawMap[key]\n")
+		g.printf("if !found {\ncontinue\n}\n")
+		g.printf("delete(rawMap, key)\n")
+		if hasPresence {
+			g.printf("v.Presence[%q] = true\n", f.jsonName)
+		}
+		g.printf("var value %s\n", g.typeString(fieldType))
+		if decodesWithJSON(fieldType) {
+			g.printf("if err := json.Unmarshal(rawValue, &value); err != nil {\nreturn err\n}\n")
+		} else {
+			g.printf("if err := %s(rawValue, &value); err != nil {\nreturn err\n}\n", g.partialmarshal(structType, "Unmarshal"))
+		}
+		g.printf("v.%s = value\n", f.name)
+		g.printf("break\n")
+		g.printf("}\n")
+	}
+	g.printf("v.Extra = rawMap\n")
+	g.printf("return nil\n")
+	g.printf("}\n\n")
+}
+
+// source returns the formatted source of the generated file.
+func (g *generator) source() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by partialmarshal-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkg.Name())
+
+	// Standard library packages are imported first, like goimports does
+	names := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iStandard, jStandard := isStandard(g.imports[names[i]]), isStandard(g.imports[names[j]])
+		if iStandard != jStandard {
+			return iStandard
+		}
+		return g.imports[names[i]] < g.imports[names[j]]
+	})
+	fmt.Fprintf(&buf, "import (\n")
+	for i, name := range names {
+		path := g.imports[name]
+		if i > 0 && isStandard(path) != isStandard(g.imports[names[i-1]]) {
+			fmt.Fprintf(&buf, "\n")
+		}
+		if name == filepath.Base(path) {
+			fmt.Fprintf(&buf, "%q\n", path)
+		} else {
+			fmt.Fprintf(&buf, "%s %q\n", name, path)
+		}
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+
+	buf.Write(g.body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// isStandard reports whether path is the import path of a standard library
+// package.
+func isStandard(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("internal", "testtypes")
+	expected, err := os.ReadFile(filepath.Join(dir, "partialmarshal_gen.go"))
+	assert.NoError(t, err)
+
+	// The methods tested against the reflection path are the ones generated now
+	source, err := generate(dir, "partialmarshal_gen.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(source))
+}
+
+func TestGenerateErrors(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inDir           string
+		outErrMsg       string
+	}{
+		{
+			"should return error when no struct type embeds extra",
+			filepath.Join("testdata", "noextra"),
+			"no struct type embeds partialmarshal.Extra in " + filepath.Join("testdata", "noextra"),
+		},
+		{
+			"should return error when a struct type already declares a method",
+			filepath.Join("testdata", "declared"),
+			"Person already declares MarshalJSON",
+		},
+		{
+			"should return error when omitempty cannot be checked",
+			filepath.Join("testdata", "omitempty"),
+			"Person.Names: omitempty is not supported for type [2][]string",
+		},
+		{
+			"should return error when a field has a partialmarshal tag",
+			filepath.Join("testdata", "tagged"),
+			"Person.Name: the partialmarshal tag is not supported",
+		},
+		{
+			"should return error when the directory holds no package",
+			filepath.Join("testdata", "missing"),
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			_, err := generate(tc.inDir, "partialmarshal_gen.go")
+			if tc.outErrMsg == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.outErrMsg)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Package external declares a struct type that embeds partialmarshal.Extra
+// without generated methods, which the generated methods of testtypes leave
+// to the reflection path.
+package external
+
+import "github.com/mrhwick/partialmarshal"
+
+// Address embeds partialmarshal.Extra and has no generated methods.
+type Address struct {
+	Street string `json:"street"`
+	partialmarshal.Extra
+}
@@ -0,0 +1,378 @@
+// Code generated by partialmarshal-gen. DO NOT EDIT.
+
+package testtypes
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mrhwick/partialmarshal"
+	"github.com/mrhwick/partialmarshal/cmd/partialmarshal-gen/internal/testtypes/external"
+)
+
+// MarshalJSON returns the JSON encoding of v, placing its extra payload into
+// the JSON output as top-level key/value pairs like partialmarshal.Marshal.
+func (v Address) MarshalJSON() ([]byte, error) {
+	object := make(map[string]interface{}, 1+len(v.Extra))
+	object["city"] = v.City
+	for key, value := range v.Extra {
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON parses the JSON-encoded data into v, placing any unmatching
+// data into its embedded Extra like partialmarshal.Unmarshal.
+func (v *Address) UnmarshalJSON(data []byte) error {
+	var rawMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawMap); err != nil {
+		return err
+	}
+	if rawMap == nil {
+		// A JSON null leaves v unchanged
+		return nil
+	}
+	for _, key := range []string{"City", "city"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.City = value
+		break
+	}
+	v.Extra = rawMap
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of v, placing its extra payload into
+// the JSON output as top-level key/value pairs like partialmarshal.Marshal.
+func (v Person) MarshalJSON() ([]byte, error) {
+	object := make(map[string]interface{}, 16+len(v.Extra))
+	object["Base"] = v.Base
+	object["name"] = v.Name
+	if v.Age != 0 {
+		object["age"] = v.Age
+	}
+	if v.Admin {
+		object["admin"] = v.Admin
+	}
+	if v.Tags != nil {
+		object["tags"] = v.Tags
+	}
+	object["scores"] = v.Scores
+	object["address"] = v.Address
+	if v.Previous != nil {
+		object["previous"] = v.Previous
+	}
+	object["addresses"] = v.Addresses
+	if v.Pointers != nil {
+		object["pointers"] = v.Pointers
+	}
+	{
+		encoded, err := partialmarshal.Marshal(v.External)
+		if err != nil {
+			return nil, err
+		}
+		object["external"] = json.RawMessage(encoded)
+	}
+	if v.Nickname.Set {
+		object["nickname"] = v.Nickname
+	}
+	if v.Manager.Set {
+		object["manager"] = v.Manager
+	}
+	object["born"] = v.Born
+	object["Untagged"] = v.Untagged
+	for key, value := range v.Extra {
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON parses the JSON-encoded data into v, placing any unmatching
+// data into its embedded Extra like partialmarshal.Unmarshal.
+func (v *Person) UnmarshalJSON(data []byte) error {
+	var rawMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawMap); err != nil {
+		return err
+	}
+	if rawMap == nil {
+		// A JSON null leaves v unchanged
+		return nil
+	}
+	for _, key := range []string{"Base"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value Base
+		if err := partialmarshal.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Base = value
+		break
+	}
+	for _, key := range []string{"Name", "name"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Name = value
+		break
+	}
+	for _, key := range []string{"Age", "age", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value int
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Age = value
+		break
+	}
+	for _, key := range []string{"Admin", "admin", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value bool
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Admin = value
+		break
+	}
+	for _, key := range []string{"Tags", "tags", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value []string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Tags = value
+		break
+	}
+	for _, key := range []string{"Scores", "scores"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value map[string]int
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Scores = value
+		break
+	}
+	for _, key := range []string{"Address", "address", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value Address
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Address = value
+		break
+	}
+	for _, key := range []string{"Previous", "previous", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value *Address
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Previous = value
+		break
+	}
+	for _, key := range []string{"Addresses", "addresses"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value []Address
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Addresses = value
+		break
+	}
+	for _, key := range []string{"Pointers", "pointers", "omitempty"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value []*Address
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Pointers = value
+		break
+	}
+	for _, key := range []string{"External", "external"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value external.Address
+		if err := partialmarshal.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.External = value
+		break
+	}
+	for _, key := range []string{"Nickname", "nickname"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value partialmarshal.Optional[string]
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Nickname = value
+		break
+	}
+	for _, key := range []string{"Manager", "manager"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value partialmarshal.Nullable[int]
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Manager = value
+		break
+	}
+	for _, key := range []string{"Born", "born"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value time.Time
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Born = value
+		break
+	}
+	for _, key := range []string{"Secret", "-"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Secret = value
+		break
+	}
+	for _, key := range []string{"Untagged"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		var value float64
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Untagged = value
+		break
+	}
+	v.Extra = rawMap
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of v, placing its extra payload into
+// the JSON output as top-level key/value pairs like partialmarshal.Marshal.
+func (v Tracked) MarshalJSON() ([]byte, error) {
+	object := make(map[string]interface{}, 2+len(v.Extra))
+	if v.Presence == nil || v.Presence.IsSet("name") {
+		object["name"] = v.Name
+	}
+	if v.Presence == nil || v.Presence.IsSet("count") {
+		object["count"] = v.Count
+	}
+	for key, value := range v.Extra {
+		object[key] = value
+	}
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON parses the JSON-encoded data into v, placing any unmatching
+// data into its embedded Extra like partialmarshal.Unmarshal.
+func (v *Tracked) UnmarshalJSON(data []byte) error {
+	var rawMap map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rawMap); err != nil {
+		return err
+	}
+	if rawMap == nil {
+		// A JSON null leaves v unchanged
+		return nil
+	}
+	v.Presence = partialmarshal.Presence{}
+	for _, key := range []string{"Name", "name"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		v.Presence["name"] = true
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Name = value
+		break
+	}
+	for _, key := range []string{"Count", "count"} {
+		rawValue, found := rawMap[key]
+		if !found {
+			continue
+		}
+		delete(rawMap, key)
+		v.Presence["count"] = true
+		var value int
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return err
+		}
+		v.Count = value
+		break
+	}
+	v.Extra = rawMap
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package testtypes declares struct types that partialmarshal-gen generates
+// methods for, so that the generated methods can be tested against the
+// reflection path.
+package testtypes
+
+import (
+	"time"
+
+	"github.com/mrhwick/partialmarshal"
+	"github.com/mrhwick/partialmarshal/cmd/partialmarshal-gen/internal/testtypes/external"
+)
+
+//go:generate go run github.com/mrhwick/partialmarshal/cmd/partialmarshal-gen
+
+// Address embeds partialmarshal.Extra and is used as a substruct.
+type Address struct {
+	City string `json:"city"`
+	partialmarshal.Extra
+}
+
+// Base is a struct without extra storage that is embedded in Person.
+type Base struct {
+	ID int `json:"id"`
+}
+
+// Person covers the kinds of fields that the generated methods handle.
+type Person struct {
+	Base
+	Name      string                          `json:"name"`
+	Age       int                             `json:"age,omitempty"`
+	Admin     bool                            `json:"admin,omitempty"`
+	Tags      []string                        `json:"tags,omitempty"`
+	Scores    map[string]int                  `json:"scores"`
+	Address   Address                         `json:"address,omitempty"`
+	Previous  *Address                        `json:"previous,omitempty"`
+	Addresses []Address                       `json:"addresses"`
+	Pointers  []*Address                      `json:"pointers,omitempty"`
+	External  external.Address                `json:"external"`
+	Nickname  partialmarshal.Optional[string] `json:"nickname"`
+	Manager   partialmarshal.Nullable[int]    `json:"manager"`
+	Born      time.Time                       `json:"born"`
+	Secret    string                          `json:"-"`
+	Untagged  float64
+	internal  string
+	partialmarshal.Extra
+}
+
+// Tracked embeds partialmarshal.Presence along with partialmarshal.Extra.
+type Tracked struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	partialmarshal.Presence
+	partialmarshal.Extra
+}
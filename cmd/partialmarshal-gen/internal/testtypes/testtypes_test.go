@@ -0,0 +1,153 @@
+package testtypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mrhwick/partialmarshal"
+	"github.com/mrhwick/partialmarshal/cmd/partialmarshal-gen/internal/testtypes/external"
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleAddress() {
+	var address Address
+	err := json.Unmarshal([]byte(`{"city": "Gopher City", "zip": "12345"}`), &address)
+	fmt.Println(err)
+	fmt.Printf("%s\n", address.Extra["zip"])
+
+	address.City = "Gopherton"
+	JSONData, _ := json.Marshal(address)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// "12345"
+	// {"city":"Gopherton","zip":"12345"}
+}
+
+func TestGeneratedUnmarshal(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		newValue        func() interface{}
+	}{
+		{
+			"should match every field and keep extra payload at every depth",
+			[]byte(`{
+				"Base": {"id": 1},
+				"name": "gopher",
+				"age": 25,
+				"admin": true,
+				"tags": ["a", "b"],
+				"scores": {"a": 1},
+				"address": {"city": "Gopher City", "zip": "12345"},
+				"previous": {"city": "Old Town", "zip": "54321"},
+				"addresses": [{"city": "One"}, {"city": "Two", "zip": "2"}],
+				"pointers": [{"city": "Three", "zip": "3"}, null],
+				"external": {"street": "Main Street", "number": 1},
+				"nickname": "go",
+				"manager": null,
+				"born": "2009-11-10T23:00:00Z",
+				"Untagged": 1.5,
+				"internal": "internal",
+				"favorite_food": "Pizza"
+			}`),
+			func() interface{} { return &Person{} },
+		},
+		{
+			"should match fields by their Go name and tag options",
+			[]byte(`{"Name": "gopher", "omitempty": 25, "Secret": "secret", "-": "dash", "Extra": 1}`),
+			func() interface{} { return &Person{} },
+		},
+		{
+			"should decode null into the zero value of fields",
+			[]byte(`{"name": null, "address": null, "previous": null, "addresses": null, "external": null, "nickname": null}`),
+			func() interface{} { return &Person{Name: "gopher", Address: Address{City: "Gopher City"}} },
+		},
+		{
+			"should decode empty object and empty arrays",
+			[]byte(`{"addresses": [], "tags": [], "scores": {}}`),
+			func() interface{} { return &Person{} },
+		},
+		{
+			"should record present fields",
+			[]byte(`{"name": "gopher", "other": true}`),
+			func() interface{} { return &Tracked{} },
+		},
+		{
+			"should return error when provided with mistyped values",
+			[]byte(`{"addresses": [{"city": 1}]}`),
+			func() interface{} { return &Person{} },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			reflected, generated := tc.newValue(), tc.newValue()
+			reflectedErr := partialmarshal.Unmarshal(tc.inData, reflected)
+			generatedErr := json.Unmarshal(tc.inData, generated)
+			assert.Equal(t, reflectedErr, generatedErr)
+			assert.Equal(t, reflected, generated)
+		})
+	}
+}
+
+func TestGeneratedMarshal(t *testing.T) {
+	address := &Address{"Gopher City", partialmarshal.Extra{"zip": []byte(`"12345"`)}}
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+	}{
+		{
+			"should write every field and extra payload at every depth",
+			Person{
+				Base:      Base{1},
+				Name:      "gopher",
+				Age:       25,
+				Admin:     true,
+				Tags:      []string{"a", "b"},
+				Scores:    map[string]int{"a": 1},
+				Address:   *address,
+				Previous:  address,
+				Addresses: []Address{*address, {}},
+				Pointers:  []*Address{address, nil},
+				External:  external.Address{Street: "Main Street", Extra: partialmarshal.Extra{"number": []byte(`1`)}},
+				Nickname:  partialmarshal.NewOptional("go"),
+				Manager:   partialmarshal.NewNull[int](),
+				Born:      time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+				Secret:    "secret",
+				Untagged:  1.5,
+				internal:  "internal",
+				Extra:     partialmarshal.Extra{"favorite_food": []byte(`"Pizza"`), "name": []byte(`"extra name"`)},
+			},
+		},
+		{
+			"should leave out empty and unset fields",
+			Person{Tags: []string{}, Pointers: []*Address{}},
+		},
+		{
+			"should write fields from a pointer",
+			&Person{Name: "gopher", Previous: &Address{}},
+		},
+		{
+			"should write only present fields",
+			Tracked{Name: "gopher", Count: 1, Presence: partialmarshal.Presence{"count": true}},
+		},
+		{
+			"should write every field without presence",
+			Tracked{Name: "gopher", Extra: partialmarshal.Extra{"<html>": []byte(`"&"`)}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			reflected, err := partialmarshal.Marshal(tc.inValue)
+			assert.NoError(t, err)
+			generated, err := json.Marshal(tc.inValue)
+			assert.NoError(t, err)
+			assert.Equal(t, string(reflected), string(generated))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Command partialmarshal-gen generates MarshalJSON and UnmarshalJSON methods
+// for the struct types of a package that embed partialmarshal.Extra.
+//
+// The generated methods match and write the fields of each struct without
+// reflecting over it, and they keep the extra payload like partialmarshal.Marshal
+// and partialmarshal.Unmarshal do, so that the types also round-trip through
+// encoding/json. It is meant to be run by go generate from the package
+// directory:
+//
+//	//go:generate go run github.com/mrhwick/partialmarshal/cmd/partialmarshal-gen
+//
+// Nested values are decoded and encoded the way the reflection path does it,
+// so struct types of other packages that embed partialmarshal.Extra still keep
+// their extra payload when they have no generated methods of their own.
+//
+// Fields with a partialmarshal struct tag are not supported, since the
+// options of the tag are only applied by the reflection path.
+//
+// The registrations of the partialmarshal package are made at run time, so
+// the generator cannot see them and the generated methods ignore them: a
+// generated type neither follows its naming strategy, runs its migrations
+// nor decodes its extensions, and when it is registered as a variant, its
+// MarshalJSON method does not write the discriminator. The output of such a
+// type then differs from the one of partialmarshal.Marshal, so types with
+// registrations are best left without generated methods.
+//
+// Since methods are promoted through embedded fields, a struct type that
+// embeds a generated type without embedding partialmarshal.Extra itself is
+// encoded by the methods of the embedded type alone.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("partialmarshal-gen: ")
+
+	dir := flag.String("dir", ".", "directory of the package to generate methods for")
+	output := flag.String("output", "partialmarshal_gen.go", "name of the generated file in the package directory")
+	flag.Parse()
+
+	source, err := generate(*dir, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(*dir, *output), source, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
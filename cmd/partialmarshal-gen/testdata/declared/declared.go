@@ -0,0 +1,12 @@
+package declared
+
+import "github.com/mrhwick/partialmarshal"
+
+type Person struct {
+	Name string `json:"name"`
+	partialmarshal.Extra
+}
+
+func (p Person) MarshalJSON() ([]byte, error) {
+	return partialmarshal.Marshal(p)
+}
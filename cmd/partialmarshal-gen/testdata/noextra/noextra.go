@@ -0,0 +1,5 @@
+package noextra
+
+type Person struct {
+	Name string `json:"name"`
+}
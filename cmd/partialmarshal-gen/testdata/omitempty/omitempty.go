@@ -0,0 +1,8 @@
+package omitempty
+
+import "github.com/mrhwick/partialmarshal"
+
+type Person struct {
+	Names [2][]string `json:"names,omitempty"`
+	partialmarshal.Extra
+}
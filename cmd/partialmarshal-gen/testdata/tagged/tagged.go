@@ -0,0 +1,8 @@
+package tagged
+
+import "github.com/mrhwick/partialmarshal"
+
+type Person struct {
+	Name string `json:"name" partialmarshal:"required"`
+	partialmarshal.Extra
+}
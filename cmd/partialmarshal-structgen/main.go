@@ -0,0 +1,67 @@
+// Command partialmarshal-structgen prints Go struct types inferred from
+// sample JSON documents, as a starting point for the types of a new API.
+//
+// Usage:
+//
+//	partialmarshal-structgen [flags] sample.json...
+//
+// The shapes of all samples are merged: keys become fields with json tags,
+// nested objects become struct types of their own, and keys that are missing
+// from some objects or hold null become partialmarshal.Optional or
+// partialmarshal.Nullable fields. Every struct type embeds
+// partialmarshal.Extra, which keeps the keys that are not fields, such as the
+// keys rarer than the -threshold flag.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("partialmarshal-structgen: ")
+
+	name := flag.String("name", "Document", "name of the struct type of the sample documents")
+	pkgName := flag.String("package", defaultPackage(), "name of the package of the generated source")
+	threshold := flag.Float64("threshold", 0, "fraction of the objects of a struct type that a key must appear in to become a field, rarer keys are left for Extra")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: partialmarshal-structgen [flags] sample.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *threshold < 0 || *threshold > 1 {
+		log.Fatalf("threshold %v is not between 0 and 1", *threshold)
+	}
+
+	root := &shape{}
+	for _, filename := range flag.Args() {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = root.addSample(data)
+		if err != nil {
+			log.Fatalf("%s: %s", filename, err)
+		}
+	}
+	source, err := render(*pkgName, *name, root, *threshold)
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(source)
+}
+
+// defaultPackage returns the package that go generate runs in, if any.
+func defaultPackage() string {
+	if pkgName := os.Getenv("GOPACKAGE"); pkgName != "" {
+		return pkgName
+	}
+	return "main"
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are the words that are written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// renderer writes the struct types of a shape as Go source.
+type renderer struct {
+	threshold float64
+	typeNames map[string]bool
+	pending   []pendingStruct
+	body      bytes.Buffer
+}
+
+// pendingStruct is a struct type that is named but not yet written.
+type pendingStruct struct {
+	name        string
+	description string
+	shape       *shape
+}
+
+// render returns the Go source of package pkgName declaring the struct type
+// name for the root shape and the struct types of its nested objects. Keys
+// that are contained in less than the threshold fraction of the objects of
+// a struct are left for its embedded partialmarshal.Extra.
+func render(pkgName, name string, root *shape, threshold float64) ([]byte, error) {
+	if root.objects == 0 || root.kinds() > 1 {
+		return nil, fmt.Errorf("samples must be JSON objects or arrays of JSON objects")
+	}
+	r := &renderer{threshold: threshold, typeNames: map[string]bool{}}
+	r.structName(name, root, func(unique string) string {
+		return unique + " is generated from sample JSON documents."
+	})
+	for len(r.pending) > 0 {
+		next := r.pending[0]
+		r.pending = r.pending[1:]
+		r.writeStruct(next)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import \"github.com/mrhwick/partialmarshal\"\n\n")
+	buf.Write(r.body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// structName reserves a type name for the struct type of s, based on name,
+// and queues the struct type to be written with the doc comment returned by
+// describe for that type name.
+func (r *renderer) structName(name string, s *shape, describe func(string) string) string {
+	unique := name
+	for suffix := 2; r.typeNames[unique]; suffix++ {
+		unique = name + strconv.Itoa(suffix)
+	}
+	r.typeNames[unique] = true
+	r.pending = append(r.pending, pendingStruct{unique, describe(unique), s})
+	return unique
+}
+
+// writeStruct writes the struct type with its fields, in the order that the
+// keys were first seen, and an embedded partialmarshal.Extra.
+func (r *renderer) writeStruct(p pendingStruct) {
+	fmt.Fprintf(&r.body, "// %s\n", p.description)
+	fmt.Fprintf(&r.body, "type %s struct {\n", p.name)
+	fieldNames := map[string]bool{"Extra": true}
+	for _, key := range p.shape.keys {
+		if !isValidTag(key) || key == "Extra" || key == "ExtraOf" || p.shape.frequency(key) < r.threshold {
+			// Keys that cannot be fields, such as those that Marshal reserves for
+			// the extra storage, are left for Extra
+			continue
+		}
+		fieldName := goName(key)
+		unique := fieldName
+		for suffix := 2; fieldNames[unique]; suffix++ {
+			unique = fieldName + strconv.Itoa(suffix)
+		}
+		fieldNames[unique] = true
+
+		field := p.shape.fields[key]
+		fieldType := r.goType(field, p.name+fieldName, key, p.name, false)
+		switch {
+		case field.count < p.shape.objects && field.kinds() > 0:
+			fieldType = "partialmarshal.Optional[" + fieldType + "]"
+		case field.nulls > 0 && field.kinds() > 0:
+			fieldType = "partialmarshal.Nullable[" + fieldType + "]"
+		}
+		fmt.Fprintf(&r.body, "%s %s `json:%q`\n", unique, fieldType, key)
+	}
+	fmt.Fprintf(&r.body, "partialmarshal.Extra\n")
+	fmt.Fprintf(&r.body, "}\n\n")
+}
+
+// goType returns the Go type of the values of s, which are found under key
+// in the parent struct type or in the elements of its arrays, naming the
+// struct types of objects after name.
+func (r *renderer) goType(s *shape, name, key, parent string, element bool) string {
+	if s.kinds() != 1 {
+		// Values that are always null or of mixed kinds are kept as they are
+		return "interface{}"
+	}
+	switch {
+	case s.bools > 0:
+		return "bool"
+	case s.floats > 0:
+		return "float64"
+	case s.ints > 0:
+		return "int64"
+	case s.strings > 0:
+		return "string"
+	case s.arrays > 0:
+		return "[]" + r.goType(s.elem, name+"Item", key, parent, true)
+	}
+	return r.structName(name, s, func(unique string) string {
+		if element {
+			return fmt.Sprintf("%s is an element of the %q array of %s.", unique, key, parent)
+		}
+		return fmt.Sprintf("%s is the %q object of %s.", unique, key, parent)
+	})
+}
+
+// goName returns the exported Go name for the JSON key.
+func goName(key string) string {
+	words := strings.FieldsFunc(key, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	var name strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			name.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		name.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	result := name.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "Field" + result
+	}
+	return result
+}
+
+// isValidTag reports whether key can be used as the name of a json tag, as
+// encoding/json accepts it.
+func isValidTag(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
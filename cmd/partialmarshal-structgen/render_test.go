@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inSamples       []string
+		inThreshold     float64
+		outSource       string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should render struct types for nested objects and array elements",
+			[]string{
+				`{"id": 1, "name": "gopher", "address": {"city": "Gopher City"}, "friends": [{"name": "one", "age": 1}]}`,
+				`{"id": 2, "name": "gopher", "address": {"city": "Gopher City", "zip": null}, "friends": [{"name": "two", "age": 1.5}]}`,
+			},
+			0,
+			`package models
+
+import "github.com/mrhwick/partialmarshal"
+
+// Person is generated from sample JSON documents.
+type Person struct {
+	ID      int64               ` + "`json:\"id\"`" + `
+	Name    string              ` + "`json:\"name\"`" + `
+	Address PersonAddress       ` + "`json:\"address\"`" + `
+	Friends []PersonFriendsItem ` + "`json:\"friends\"`" + `
+	partialmarshal.Extra
+}
+
+// PersonAddress is the "address" object of Person.
+type PersonAddress struct {
+	City string      ` + "`json:\"city\"`" + `
+	Zip  interface{} ` + "`json:\"zip\"`" + `
+	partialmarshal.Extra
+}
+
+// PersonFriendsItem is an element of the "friends" array of Person.
+type PersonFriendsItem struct {
+	Name string  ` + "`json:\"name\"`" + `
+	Age  float64 ` + "`json:\"age\"`" + `
+	partialmarshal.Extra
+}
+`,
+			"",
+		},
+		{
+			"should render optional and nullable fields",
+			[]string{`{"a": 1, "b": null, "c": "one"}`, `{"b": "two", "c": "two", "d": [1, "one"]}`},
+			0,
+			`package models
+
+import "github.com/mrhwick/partialmarshal"
+
+// Person is generated from sample JSON documents.
+type Person struct {
+	A partialmarshal.Optional[int64]         ` + "`json:\"a\"`" + `
+	B partialmarshal.Nullable[string]        ` + "`json:\"b\"`" + `
+	C string                                 ` + "`json:\"c\"`" + `
+	D partialmarshal.Optional[[]interface{}] ` + "`json:\"d\"`" + `
+	partialmarshal.Extra
+}
+`,
+			"",
+		},
+		{
+			"should leave rare and reserved keys for extra",
+			[]string{`{"a": 1, "b": 1, "Extra": 1, "c,d": 1}`, `{"a": 2}`, `{"a": 3, "b": 3}`},
+			0.6,
+			`package models
+
+import "github.com/mrhwick/partialmarshal"
+
+// Person is generated from sample JSON documents.
+type Person struct {
+	A int64                          ` + "`json:\"a\"`" + `
+	B partialmarshal.Optional[int64] ` + "`json:\"b\"`" + `
+	partialmarshal.Extra
+}
+`,
+			"",
+		},
+		{
+			"should keep type and field names unique",
+			[]string{`{"user_id": 1, "user-id": 2, "a": {}, "A": {}}`},
+			0,
+			`package models
+
+import "github.com/mrhwick/partialmarshal"
+
+// Person is generated from sample JSON documents.
+type Person struct {
+	UserID  int64    ` + "`json:\"user_id\"`" + `
+	UserID2 int64    ` + "`json:\"user-id\"`" + `
+	A       PersonA  ` + "`json:\"a\"`" + `
+	A2      PersonA2 ` + "`json:\"A\"`" + `
+	partialmarshal.Extra
+}
+
+// PersonA is the "a" object of Person.
+type PersonA struct {
+	partialmarshal.Extra
+}
+
+// PersonA2 is the "A" object of Person.
+type PersonA2 struct {
+	partialmarshal.Extra
+}
+`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when samples are not objects",
+			[]string{`{"a": 1}`, `[1]`},
+			0,
+			"",
+			"samples must be JSON objects or arrays of JSON objects",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			root := &shape{}
+			for _, sample := range tc.inSamples {
+				assert.NoError(t, root.addSample([]byte(sample)))
+			}
+			result, err := render("models", "Person", root, tc.inThreshold)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outSource, string(result))
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	testCases := map[string]string{
+		"name":         "Name",
+		"first_name":   "FirstName",
+		"homepage-url": "HomepageURL",
+		"userId":       "UserId",
+		"2fa":          "Field2fa",
+		"$":            "Field",
+		"ünïcode":      "Ünïcode",
+	}
+	for key, name := range testCases {
+		assert.Equal(t, name, goName(key), key)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// shape is the merged structure of the JSON values seen at one place of the
+// sample documents.
+type shape struct {
+	count   int // number of values seen, null included
+	nulls   int
+	bools   int
+	ints    int
+	floats  int
+	strings int
+	arrays  int
+	objects int
+	elem    *shape            // merged elements of the arrays
+	fields  map[string]*shape // merged values of the object keys
+	keys    []string          // object keys in the order they were first seen
+}
+
+// member is a key/value pair of a JSON object, kept in document order.
+type member struct {
+	key   string
+	value interface{}
+}
+
+// object is a JSON object whose members are kept in document order.
+type object []member
+
+// addSample parses the JSON document in data and merges it into s. A
+// document holding an array adds each of its elements as a sample.
+func (s *shape) addSample(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+	if elements, ok := value.([]interface{}); ok {
+		for _, element := range elements {
+			s.add(element)
+		}
+		return nil
+	}
+	s.add(value)
+	return nil
+}
+
+// decodeOrdered decodes the next JSON value of decoder, keeping objects as
+// object so that their keys stay in document order.
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		result := object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, member{key.(string), value})
+		}
+		_, err = decoder.Token()
+		return result, err
+	case json.Delim('['):
+		result := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		_, err = decoder.Token()
+		return result, err
+	}
+	return token, nil
+}
+
+// add merges the decoded JSON value into s.
+func (s *shape) add(value interface{}) {
+	s.count++
+	switch typedValue := value.(type) {
+	case nil:
+		s.nulls++
+	case bool:
+		s.bools++
+	case json.Number:
+		if isInteger(typedValue) {
+			s.ints++
+		} else {
+			s.floats++
+		}
+	case string:
+		s.strings++
+	case []interface{}:
+		s.arrays++
+		if s.elem == nil {
+			s.elem = &shape{}
+		}
+		for _, element := range typedValue {
+			s.elem.add(element)
+		}
+	case object:
+		s.objects++
+		if s.fields == nil {
+			s.fields = map[string]*shape{}
+		}
+		for _, m := range typedValue {
+			field, found := s.fields[m.key]
+			if !found {
+				field = &shape{}
+				s.fields[m.key] = field
+				s.keys = append(s.keys, m.key)
+			}
+			field.add(m.value)
+		}
+	}
+}
+
+// isInteger reports whether number fits an int64.
+func isInteger(number json.Number) bool {
+	if strings.ContainsAny(number.String(), ".eE") {
+		return false
+	}
+	_, err := number.Int64()
+	return err == nil
+}
+
+// kinds returns the number of distinct non-null kinds of values in s, with
+// integers and floats counted as one kind.
+func (s *shape) kinds() int {
+	kinds := 0
+	for _, count := range []int{s.bools, s.ints + s.floats, s.strings, s.arrays, s.objects} {
+		if count > 0 {
+			kinds++
+		}
+	}
+	return kinds
+}
+
+// frequency returns the fraction of the objects of s that contain key.
+func (s *shape) frequency(key string) float64 {
+	return float64(s.fields[key].count) / float64(s.objects)
+}
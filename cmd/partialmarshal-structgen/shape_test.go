@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeAddSample(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inSamples       []string
+		outShape        *shape
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should merge keys of objects in the order they were first seen",
+			[]string{`{"b": 1, "a": "one"}`, `{"c": null, "a": "two"}`},
+			&shape{
+				count:   2,
+				objects: 2,
+				fields: map[string]*shape{
+					"a": {count: 2, strings: 2},
+					"b": {count: 1, ints: 1},
+					"c": {count: 1, nulls: 1},
+				},
+				keys: []string{"b", "a", "c"},
+			},
+			"",
+		},
+		{
+			"should add elements of top-level arrays as samples",
+			[]string{`[{"a": 1}, {"a": 1.5}]`},
+			&shape{
+				count:   2,
+				objects: 2,
+				fields: map[string]*shape{
+					"a": {count: 2, ints: 1, floats: 1},
+				},
+				keys: []string{"a"},
+			},
+			"",
+		},
+		{
+			"should merge elements of nested arrays",
+			[]string{`{"a": [true, 1e3, 99999999999999999999]}`, `{"a": []}`},
+			&shape{
+				count:   2,
+				objects: 2,
+				fields: map[string]*shape{
+					"a": {count: 2, arrays: 2, elem: &shape{count: 3, bools: 1, floats: 2}},
+				},
+				keys: []string{"a"},
+			},
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when provided with malformed JSON",
+			[]string{`{decidedly not json in format`},
+			nil,
+			"invalid character 'd' looking for beginning of value",
+		},
+		{
+			"should return error when provided with more than one document",
+			[]string{`{} {}`},
+			nil,
+			"invalid data after top-level value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			result := &shape{}
+			var err error
+			for _, sample := range tc.inSamples {
+				err = result.addSample([]byte(sample))
+			}
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outShape, result)
+		})
+	}
+}
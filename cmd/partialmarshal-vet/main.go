@@ -0,0 +1,170 @@
+// Command partialmarshal-vet runs the extracheck analyzer, which reports
+// types carrying partialmarshal.Extra passed to encoding/json, on packages.
+//
+// Usage:
+//
+//	partialmarshal-vet [directory...]
+//
+// A directory ending in /... also checks the packages of its subdirectories.
+// Diagnostics are printed as file:line:column: message, and the command exits
+// with status 1 when any is reported.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mrhwick/partialmarshal/analysis/extracheck"
+	"golang.org/x/tools/go/analysis"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("partialmarshal-vet: ")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: partialmarshal-vet [directory...]\n\n%s\n", extracheck.Analyzer.Doc)
+	}
+	flag.Parse()
+	if err := analysis.Validate([]*analysis.Analyzer{extracheck.Analyzer}); err != nil {
+		log.Fatal(err)
+	}
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	dirs, err := expandPatterns(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	sourceImporter := importer.ForCompiler(fset, "source", nil)
+	var diagnostics []string
+	for _, dir := range dirs {
+		found, err := check(fset, sourceImporter, dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Println(diagnostic)
+	}
+	if len(diagnostics) > 0 {
+		os.Exit(1)
+	}
+}
+
+// expandPatterns returns the directories named by patterns, walking the
+// subdirectories of those ending in /... except for testdata and vendor
+// directories.
+func expandPatterns(patterns []string) ([]string, error) {
+	var dirs []string
+	for _, pattern := range patterns {
+		root, recursive := strings.CutSuffix(pattern, "/...")
+		if !recursive {
+			dirs = append(dirs, pattern)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.IsDir() {
+				return err
+			}
+			name := entry.Name()
+			if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// check type-checks the package in dir along with its tests, and its external
+// test package if it has one, and returns the diagnostics of the analyzer,
+// formatted and sorted by position.
+func check(fset *token.FileSet, sourceImporter types.Importer, dir string) ([]string, error) {
+	buildPackage, err := build.ImportDir(dir, 0)
+	var noGoError *build.NoGoError
+	if errors.As(err, &noGoError) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics, err := checkFiles(fset, sourceImporter, dir, buildPackage.ImportPath, append(buildPackage.GoFiles, buildPackage.TestGoFiles...))
+	if err != nil {
+		return nil, err
+	}
+	if len(buildPackage.XTestGoFiles) > 0 {
+		found, err := checkFiles(fset, sourceImporter, dir, buildPackage.ImportPath+"_test", buildPackage.XTestGoFiles)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, found...)
+	}
+	return diagnostics, nil
+}
+
+// checkFiles type-checks the named files of dir as the package at importPath
+// and returns the diagnostics of the analyzer, formatted and sorted by
+// position.
+func checkFiles(fset *token.FileSet, sourceImporter types.Importer, dir, importPath string, names []string) ([]string, error) {
+	var files []*ast.File
+	for _, name := range names {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	info := &types.Info{
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	config := types.Config{Importer: sourceImporter}
+	pkg, err := config.Check(importPath, fset, files, info)
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:  extracheck.Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report:    func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d) },
+	}
+	_, err = extracheck.Analyzer.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(diagnostics, func(i, j int) bool { return diagnostics[i].Pos < diagnostics[j].Pos })
+	formatted := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		formatted = append(formatted, fmt.Sprintf("%s: %s", fset.Position(diagnostic.Pos), diagnostic.Message))
+	}
+	return formatted, nil
+}
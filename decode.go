@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -14,7 +15,39 @@ import (
 // partialmarshal.Extra type as an embedded type in v and places any
 // unmatching data into the embedded Extra map.
 //
+// Like encoding/json, a JSON array replaces the elements of the slice it is
+// decoded into, and an empty array decodes into an empty, non-nil slice.
+//
+// Fields tagged `partialmarshal:"required"` whose key is missing are
+// reported, at any nesting depth, as a *RequiredError, and fields tagged
+// `partialmarshal:"default=..."` whose key is missing are set to the default.
+//
+// Extra keys that break the rules registered by RegisterExtraRules are
+// reported as an *ExtraRuleError.
 func Unmarshal(data []byte, v interface{}) error {
+	err := unmarshal(data, v)
+	if err != nil {
+		return err
+	}
+	return checkExtraRules(data, reflect.TypeOf(v))
+}
+
+// unmarshal is Unmarshal without the check of the extra rules, which is done
+// once for the whole document.
+func unmarshal(data []byte, v interface{}) error {
+	if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr && hasVariants(t.Elem()) && !reflect.ValueOf(v).IsNil() {
+		// Interfaces with registered variants are decoded into the concrete
+		// type selected by the discriminator
+		value, err := decodeVariant(data, t.Elem())
+		if value.IsValid() {
+			reflect.ValueOf(v).Elem().Set(value)
+		}
+		return err
+	}
+	if document, ok := v.(documentDecoder); ok && !reflect.ValueOf(v).IsNil() {
+		// Documents decode themselves
+		return document.decodeDocument(data)
+	}
 	if bytes.HasPrefix(data, []byte("[")) {
 		return unmarshalArray(data, v)
 	}
@@ -39,24 +72,33 @@ func unmarshalArray(data json.RawMessage, v interface{}) error {
 			Type: reflect.TypeOf(v),
 		}
 	}
-	for _, obj := range JSONObjectList {
-		sliceElementType := reflectedValue.Type().Elem()
-		temporarySliceElement := reflect.New(sliceElementType).Interface()
-		err := Unmarshal(obj, temporarySliceElement)
-		if err != nil {
+	reflectedValue.Set(reflect.MakeSlice(reflectedValue.Type(), 0, len(JSONObjectList)))
+	var missing []string
+	for i, obj := range JSONObjectList {
+		sliceElement, err := decodeValue(obj, reflectedValue.Type().Elem())
+		if requiredErr := asRequiredError(err); requiredErr != nil {
+			missing = append(missing, requiredErr.pathsBelow(strconv.Itoa(i))...)
+		} else if err != nil {
 			return err
 		}
-		reflectedValue.Set(reflect.Append(reflectedValue, reflect.Indirect(reflect.ValueOf(temporarySliceElement))))
+		reflectedValue.Set(reflect.Append(reflectedValue, sliceElement))
+	}
+	if len(missing) > 0 {
+		return &RequiredError{Paths: missing}
 	}
 	return nil
 }
 
 func unmarshalObject(data json.RawMessage, v interface{}) error {
-	// 1. Check for a valid pointer to value of kind struct.
+	// 1. Check for a valid pointer to value of kind struct with valid tags.
 	reflectedValue, err := getReflectedValue(v)
 	if err != nil {
 		return err
 	}
+	err = checkTags(reflectedValue.Type())
+	if err != nil {
+		return err
+	}
 
 	// 2. Create the json.RawMessage map of this JSON object
 	var rawMap map[string]json.RawMessage
@@ -65,70 +107,262 @@ func unmarshalObject(data json.RawMessage, v interface{}) error {
 		return err
 	}
 
-	// 3. Decode matching data into the struct and recursively call for substructs
-	err = decodeMatching(rawMap, reflectedValue)
+	// 3. Migrate the object to the current version of the struct type
+	err = upcast(rawMap, reflectedValue.Type())
 	if err != nil {
 		return err
 	}
 
-	// 4. Put Extra values into the Extra nested struct
-	extraField := reflectedValue.FieldByName("Extra")
+	// 4. Decode matching data into the struct and recursively call for substructs,
+	// going on when required keys are missing so that all of them are reported
+	matchErr := decodeMatching(rawMap, reflectedValue)
+	if matchErr != nil && asRequiredError(matchErr) == nil {
+		return matchErr
+	}
+
+	// 5. Decode the registered extension keys into Extensions, lifting the keys
+	// kept under the envelope key of Extra first
+	liftEnvelope(rawMap, envelopeKey(reflectedValue.Type()))
+	missing, err := decodeExtensions(rawMap, reflectedValue)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		if requiredErr := asRequiredError(matchErr); requiredErr != nil {
+			missing = append(requiredErr.Paths, missing...)
+		}
+		matchErr = &RequiredError{Paths: missing}
+	}
+
+	// 6. Put Extra values into the Extra nested struct
+	extraField := extraFieldOf(reflectedValue)
 	if extraField.IsValid() {
 		extraField.Set(reflect.ValueOf(rawMap))
+	} else if extraOf := extraOfField(reflectedValue); extraOf.IsValid() {
+		if extraErr := decodeExtraOf(rawMap, extraOf); extraErr != nil {
+			return extraErr
+		}
 	}
 
-	return nil
+	return matchErr
 }
 
-func popValueByField(rawMap map[string]json.RawMessage, field reflect.StructField) (json.RawMessage, bool) {
-	rawValue, found := rawMap[field.Name]
-	if !found {
-		// Attempt match by JSON tags.
-		tags := strings.Split(field.Tag.Get("json"), ",")
-		for _, tag := range tags {
-			rawValue, found = rawMap[tag]
-			if found {
-				delete(rawMap, tag)
-				break
+// fieldKeys returns the JSON keys that identify field, in the order that
+// they are tried when matching a JSON object against a struct.
+//
+// The aliases of the partialmarshal tag are tried after the name of the
+// field, unless the name is listed among them to set its precedence. A field
+// without a json tag name only matches the key that the naming strategy of
+// its struct type derives from its Go name, when there is one.
+func fieldKeys(field reflect.StructField, naming NamingStrategy) []string {
+	tag, _ := parseFieldTag(field)
+	keys := []string{field.Name}
+	if name, _ := parseTag(field.Tag.Get("json")); tag.name != "" {
+		keys = append(keys, tag.name)
+	} else if name == "" && naming != nil {
+		keys = []string{naming(field.Name)}
+	} else {
+		for _, key := range strings.Split(field.Tag.Get("json"), ",") {
+			if key != "" {
+				keys = append(keys, key)
 			}
 		}
+	}
 
-		if !found {
-			// Still no match found, continue to next struct field
-			return rawValue, false
+	aliases := tag.aliases
+	if len(aliases) == 0 {
+		return keys
+	}
+	ordered := make([]string, 0, len(keys)+len(aliases))
+	listed := false
+	for _, alias := range aliases {
+		if alias != field.Name && alias != jsonName(field, naming) {
+			ordered = append(ordered, alias)
+		} else if !listed {
+			ordered = append(ordered, keys...)
+			listed = true
 		}
-	} else {
-		delete(rawMap, field.Name)
 	}
-	return rawValue, true
+	if !listed {
+		ordered = append(keys, ordered...)
+	}
+	return ordered
+}
+
+// matchingKey returns the key of rawMap that matches field.
+func matchingKey(rawMap map[string]json.RawMessage, field reflect.StructField, naming NamingStrategy) (string, bool) {
+	tag, _ := parseFieldTag(field)
+	for _, key := range fieldKeys(field, naming) {
+		if _, found := lookupPath(rawMap, keyPath(key, tag)); found {
+			return key, true
+		}
+	}
+	// No match found by field.Name or JSON tags.
+	return "", false
+}
+
+func popValueByField(rawMap map[string]json.RawMessage, field reflect.StructField, naming NamingStrategy) (json.RawMessage, bool) {
+	_, rawValue, found := popKeyByField(rawMap, field, naming)
+	return rawValue, found
+}
+
+// popKeyByField removes the key of rawMap that matches field, along with
+// the other aliases of field, and returns the path of the key and its value.
+func popKeyByField(rawMap map[string]json.RawMessage, field reflect.StructField, naming NamingStrategy) ([]string, json.RawMessage, bool) {
+	key, found := matchingKey(rawMap, field, naming)
+	if !found {
+		return nil, nil, false
+	}
+	tag, _ := parseFieldTag(field)
+	path := keyPath(key, tag)
+	rawValue, _ := popPath(rawMap, path)
+	for _, alias := range tag.aliases {
+		popPath(rawMap, keyPath(alias, tag))
+	}
+	return path, rawValue, true
 }
 
 func decodeMatching(rawMap map[string]json.RawMessage, reflectedValue reflect.Value) error {
+	// Start recording present fields if the struct embeds Presence
+	var presence Presence
+	if presenceField := reflectedValue.FieldByName("Presence"); presenceField.IsValid() && presenceField.Type() == reflect.TypeOf(Presence{}) {
+		presence = Presence{}
+		presenceField.Set(reflect.ValueOf(presence))
+	}
+	var missing []string
+	naming := namingFor(reflectedValue.Type())
+
 	for i := 0; i < reflectedValue.Type().NumField(); i++ {
 		field := reflectedValue.Type().Field(i)
-		// Attempt match by field.Name
-		rawValue, found := popValueByField(rawMap, field)
-		if !found {
+		if isStorageField(field) || field.PkgPath != "" {
+			// Presence, extra storage, extensions and unexported fields are never decoded
 			continue
 		}
-
-		temp := reflect.New(field.Type).Interface()
-
-		if field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Slice {
-			err := Unmarshal(rawValue, temp)
-			if err != nil {
+		tag, _ := parseFieldTag(field)
+		if tag.skip {
+			continue
+		}
+		if tag.inline {
+			// Inline fields are matched against the keys of this object
+			fieldValue := reflectedValue.Field(i)
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			err := decodeMatching(rawMap, fieldValue)
+			if requiredErr := asRequiredError(err); requiredErr != nil {
+				missing = append(missing, requiredErr.Paths...)
+			} else if err != nil {
 				return err
 			}
-		} else {
-			err := json.Unmarshal(rawValue, &temp)
+			continue
+		}
+		// Attempt match by field.Name
+		path, rawValue, found := popKeyByField(rawMap, field, naming)
+		if !found {
+			// Report missing required keys and fill in defaults
+			if tag.required {
+				missing = append(missing, formatPointer(fieldPath(field, naming)))
+			}
+			err := setDefault(reflectedValue.Field(i), field)
 			if err != nil {
 				return err
 			}
+			continue
+		}
+		if presence != nil {
+			presence[jsonName(field, naming)] = true
 		}
 
-		actualValue := reflect.Indirect(reflect.ValueOf(temp))
+		actualValue, err := decodeValue(rawValue, field.Type)
+		if requiredErr := asRequiredError(err); requiredErr != nil {
+			missing = append(missing, requiredErr.pathsBelow(path...)...)
+		} else if err != nil {
+			return err
+		}
 		reflectedValue.FieldByName(field.Name).Set(actualValue)
 
 	}
+	if len(missing) > 0 {
+		return &RequiredError{Paths: missing}
+	}
 	return nil
 }
+
+// popFields removes the keys of rawMap that match the fields of the struct
+// type t, including the fields of inline structs, and calls visit for each
+// of them with the path of the key.
+func popFields(rawMap map[string]json.RawMessage, t reflect.Type, visit func(field reflect.StructField, path []string, rawValue json.RawMessage)) {
+	naming := namingFor(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isStorageField(field) || field.PkgPath != "" {
+			continue
+		}
+		tag, _ := parseFieldTag(field)
+		switch {
+		case tag.skip:
+		case tag.inline:
+			popFields(rawMap, inlineType(field.Type), visit)
+		default:
+			if path, rawValue, found := popKeyByField(rawMap, field, naming); found {
+				visit(field, path, rawValue)
+			}
+		}
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decodeValue decodes rawValue into a new value of type valueType, keeping
+// extra payloads for structs, pointers to structs, slices of structs and
+// registered variants of interfaces.
+//
+// The value is also returned along with a *RequiredError.
+func decodeValue(rawValue json.RawMessage, valueType reflect.Type) (reflect.Value, error) {
+	if hasVariants(valueType) {
+		return decodeVariant(rawValue, valueType)
+	}
+	if isStructPointer(valueType) && !valueType.Implements(unmarshalerType) {
+		return decodeStructPointer(rawValue, valueType)
+	}
+	temp := reflect.New(valueType).Interface()
+
+	var err error
+	if document, ok := temp.(documentDecoder); ok {
+		// Documents leave the extra rules to the check of the whole document
+		err = document.decodeDocument(rawValue)
+	} else if reflect.PtrTo(valueType).Implements(unmarshalerType) {
+		// Types that decode themselves, such as Optional, are left to do so.
+		err = json.Unmarshal(rawValue, temp)
+	} else if valueType.Kind() == reflect.Struct || valueType.Kind() == reflect.Slice {
+		err = unmarshal(rawValue, temp)
+	} else {
+		err = json.Unmarshal(rawValue, temp)
+	}
+	if err != nil && asRequiredError(err) == nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.Indirect(reflect.ValueOf(temp)), err
+}
+
+// decodeStructPointer decodes rawValue into a new pointer of type valueType
+// through the struct it points to, so that the struct keeps its extra payload,
+// required keys and defaults. A JSON null decodes into a nil pointer.
+//
+// The value is also returned along with a *RequiredError.
+func decodeStructPointer(rawValue json.RawMessage, valueType reflect.Type) (reflect.Value, error) {
+	if isJSONNull(rawValue) {
+		return reflect.Zero(valueType), nil
+	}
+	value, err := decodeValue(rawValue, valueType.Elem())
+	if err != nil && asRequiredError(err) == nil {
+		return reflect.Value{}, err
+	}
+	pointer := reflect.New(valueType.Elem())
+	pointer.Elem().Set(value)
+	return pointer, err
+}
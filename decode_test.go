@@ -324,6 +324,85 @@ func TestUnmarshal(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"should unmarshal empty JSON array into empty slice",
+			[]byte(`{"field_one": []}`),
+			&struct {
+				FieldOne []struct {
+					Extra
+				} `json:"field_one"`
+			}{},
+			&struct {
+				FieldOne []struct {
+					Extra
+				} `json:"field_one"`
+			}{
+				[]struct {
+					Extra
+				}{},
+			},
+			"",
+		},
+		{
+			"should replace the elements of a slice with those of the JSON array",
+			[]byte(`[{"field_one": "two"}]`),
+			&[]struct {
+				FieldOne string `json:"field_one"`
+			}{{"one"}, {"three"}},
+			&[]struct {
+				FieldOne string `json:"field_one"`
+			}{{"two"}},
+			"",
+		},
+		{
+			"should replace the elements of slice fields with those of the JSON array",
+			[]byte(`{"field_one": [{"sub_field_one": "two"}]}`),
+			&struct {
+				FieldOne []struct {
+					SubFieldOne string `json:"sub_field_one"`
+				} `json:"field_one"`
+			}{[]struct {
+				SubFieldOne string `json:"sub_field_one"`
+			}{{"one"}}},
+			&struct {
+				FieldOne []struct {
+					SubFieldOne string `json:"sub_field_one"`
+				} `json:"field_one"`
+			}{[]struct {
+				SubFieldOne string `json:"sub_field_one"`
+			}{{"two"}}},
+			"",
+		},
+		{
+			"should unmarshal null into zero value of field",
+			[]byte(`{"field_one": null, "field_two": null}`),
+			&struct {
+				FieldOne int            `json:"field_one"`
+				FieldTwo map[string]int `json:"field_two"`
+				Extra
+			}{1, map[string]int{"a": 1}, nil},
+			&struct {
+				FieldOne int            `json:"field_one"`
+				FieldTwo map[string]int `json:"field_two"`
+				Extra
+			}{0, nil, Extra{}},
+			"",
+		},
+		{
+			"should keep keys named after the extra field in extra",
+			[]byte(`{"Extra": "value one"}`),
+			&struct {
+				Extra
+			}{},
+			&struct {
+				Extra
+			}{
+				Extra{
+					"Extra": []byte(`"value one"`),
+				},
+			},
+			"",
+		},
 		// Sad Path Cases
 		{
 			"should return error when provided value not struct pointer",
@@ -389,6 +468,19 @@ func TestPopValueByField(t *testing.T) {
 			[]byte(`"value two"`),
 			true,
 		},
+		{
+			"should find value matching field aliases",
+			map[string]json.RawMessage{
+				"fieldTwo": []byte("\"value two\""),
+			},
+			reflect.StructField{
+				Name: "FieldTwo",
+				Tag:  `json:"field_two" partialmarshal:"aliases=field_2|fieldTwo"`,
+			},
+			"fieldTwo",
+			[]byte(`"value two"`),
+			true,
+		},
 		// Sad Path
 		{
 			"should return false found for no matching field",
@@ -406,7 +498,7 @@ func TestPopValueByField(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.testDescription, func(t *testing.T) {
-			value, found := popValueByField(tc.inMap, tc.inField)
+			value, found := popValueByField(tc.inMap, tc.inField, nil)
 			assert.Equal(t, tc.outRawValue, value)
 			assert.Equal(t, tc.outFound, found)
 			if tc.outFound {
@@ -417,6 +509,86 @@ func TestPopValueByField(t *testing.T) {
 	}
 }
 
+func TestFieldKeys(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inTag           reflect.StructTag
+		outKeys         []string
+	}{
+		{
+			"should try the field name and then the json tag",
+			`json:"name"`,
+			[]string{"Name", "name"},
+		},
+		{
+			"should try aliases after the name",
+			`json:"name" partialmarshal:"aliases=full_name|fullName"`,
+			[]string{"Name", "name", "full_name", "fullName"},
+		},
+		{
+			"should try the name where it is listed among aliases",
+			`json:"name" partialmarshal:"aliases=full_name|name|fullName"`,
+			[]string{"full_name", "Name", "name", "fullName"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			assert.Equal(t, tc.outKeys, fieldKeys(reflect.StructField{Name: "Name", Tag: tc.inTag}, nil))
+		})
+	}
+}
+
+func TestUnmarshalAliases(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one" partialmarshal:"aliases=subFieldOne"`
+		Extra
+	}
+	type testStruct struct {
+		Name      string      `json:"name" partialmarshal:"aliases=full_name|fullName"`
+		Email     string      `json:"email" partialmarshal:"aliases=mail|email"`
+		SubStruct []subStruct `json:"sub_struct" partialmarshal:"aliases=subStruct"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+	}{
+		{
+			"should decode a value from an alias",
+			[]byte(`{"full_name": "gopher", "subStruct": [{"subFieldOne": "one", "other": 1}]}`),
+			`{"name":"gopher","email":"","sub_struct":[{"sub_field_one":"one","other":1}]}`,
+		},
+		{
+			"should prefer the name over aliases",
+			[]byte(`{"fullName": "gopher2", "name": "gopher", "full_name": "gopher3"}`),
+			`{"name":"gopher","email":"","sub_struct":null}`,
+		},
+		{
+			"should prefer aliases by their order",
+			[]byte(`{"fullName": "gopher2", "full_name": "gopher"}`),
+			`{"name":"gopher","email":"","sub_struct":null}`,
+		},
+		{
+			"should prefer aliases listed before the name",
+			[]byte(`{"email": "a@example.com", "mail": "b@example.com"}`),
+			`{"name":"","email":"b@example.com","sub_struct":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			assert.NoError(t, err)
+
+			// Marshal writes the name, and consumed aliases are not kept in Extra
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
 func TestDecodeMatching(t *testing.T) {
 	type testStruct struct {
 		FieldOne string `json:"field_one"`
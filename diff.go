@@ -0,0 +1,166 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// ChangeType describes how a value differs between two documents.
+type ChangeType string
+
+// The kinds of change reported by Diff.
+const (
+	Added   ChangeType = "added"
+	Removed ChangeType = "removed"
+	Changed ChangeType = "changed"
+)
+
+// Change is a single difference found by Diff. Path is the JSON Pointer
+// of the value that differs, From is its encoding in the first value (nil
+// when it was added) and To is its encoding in the second value (nil when
+// it was removed).
+type Change struct {
+	Type ChangeType
+	Path string
+	From json.RawMessage
+	To   json.RawMessage
+}
+
+// Changes is the list of differences returned by Diff.
+type Changes []Change
+
+// Diff returns the changes needed to turn a into b.
+//
+// The values are compared by their JSON encoding as produced by Marshal, so
+// typed fields and the content of partialmarshal.Extra are compared alike,
+// independent of key order, whitespace or number formatting. Object keys are
+// reported in sorted order and array elements by index.
+func Diff(a, b interface{}) (Changes, error) {
+	documents := make([]interface{}, 2)
+	for i, v := range []interface{}{a, b} {
+		var err error
+		documents[i], err = marshalDocument(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes := Changes{}
+	err := diffDocuments(documents[0], documents[1], []string{}, &changes)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Patch returns the changes as an RFC 6902 JSON Patch document that can be
+// passed to ApplyPatch.
+func (c Changes) Patch() ([]byte, error) {
+	operations := make([]patchOperation, 0, len(c))
+	for _, change := range c {
+		operation := patchOperation{Path: change.Path, Value: change.To}
+		switch change.Type {
+		case Added:
+			operation.Op = "add"
+		case Removed:
+			operation.Op = "remove"
+		case Changed:
+			operation.Op = "replace"
+		}
+		operations = append(operations, operation)
+	}
+	return json.Marshal(operations)
+}
+
+func diffDocuments(a, b interface{}, path []string, changes *Changes) error {
+	switch aValue := a.(type) {
+	case map[string]interface{}:
+		if bValue, ok := b.(map[string]interface{}); ok {
+			return diffObjects(aValue, bValue, path, changes)
+		}
+	case []interface{}:
+		if bValue, ok := b.([]interface{}); ok {
+			return diffArrays(aValue, bValue, path, changes)
+		}
+	}
+	if documentsEqual(a, b) {
+		return nil
+	}
+	return appendChange(changes, Changed, path, a, b)
+}
+
+func diffObjects(a, b map[string]interface{}, path []string, changes *Changes) error {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		aValue, inA := a[key]
+		bValue, inB := b[key]
+		keyPath := append(path[:len(path):len(path)], key)
+		var err error
+		switch {
+		case !inA:
+			err = appendChange(changes, Added, keyPath, nil, bValue)
+		case !inB:
+			err = appendChange(changes, Removed, keyPath, aValue, nil)
+		default:
+			err = diffDocuments(aValue, bValue, keyPath, changes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffArrays(a, b []interface{}, path []string, changes *Changes) error {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		err := diffDocuments(a[i], b[i], append(path[:len(path):len(path)], strconv.Itoa(i)), changes)
+		if err != nil {
+			return err
+		}
+	}
+	for i := len(a); i < len(b); i++ {
+		err := appendChange(changes, Added, append(path[:len(path):len(path)], strconv.Itoa(i)), nil, b[i])
+		if err != nil {
+			return err
+		}
+	}
+	// Removals are listed from the end so that the indexes stay valid when
+	// the changes are applied in order.
+	for i := len(a) - 1; i >= len(b); i-- {
+		err := appendChange(changes, Removed, append(path[:len(path):len(path)], strconv.Itoa(i)), a[i], nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendChange(changes *Changes, changeType ChangeType, path []string, from, to interface{}) error {
+	change := Change{Type: changeType, Path: formatPointer(path)}
+	var err error
+	if changeType != Added {
+		change.From, err = json.Marshal(from)
+		if err != nil {
+			return err
+		}
+	}
+	if changeType != Removed {
+		change.To, err = json.Marshal(to)
+		if err != nil {
+			return err
+		}
+	}
+	*changes = append(*changes, change)
+	return nil
+}
@@ -0,0 +1,151 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleDiff() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo string `json:"example_field_two"`
+		Extra
+	}
+
+	stored := examplestruct{
+		"Value 1",
+		"Value 2",
+		Extra{
+			"some_other_field": []byte(`{"a": 1, "b": 2}`),
+		},
+	}
+	incoming := examplestruct{
+		"Value 1",
+		"New value 2",
+		Extra{
+			"some_other_field": []byte(`{"b":2,"a":1.0}`),
+			"some_new_field":   []byte(`true`),
+		},
+	}
+
+	changes, _ := Diff(stored, incoming)
+	for _, change := range changes {
+		fmt.Printf("%s %s: %s\n", change.Type, change.Path, change.To)
+	}
+	patch, _ := changes.Patch()
+	fmt.Println(string(patch))
+
+	// Output:
+	// changed /example_field_two: "New value 2"
+	// added /some_new_field: true
+	// [{"op":"replace","path":"/example_field_two","value":"New value 2"},{"op":"add","path":"/some_new_field","value":true}]
+}
+
+func TestDiff(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string      `json:"field_one"`
+		FieldTwo        float64     `json:"field_two"`
+		FieldSubStructs []subStruct `json:"field_sub_structs"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inA             interface{}
+		inB             interface{}
+		outChanges      Changes
+	}{
+		{
+			"should report no changes for equal values",
+			testStruct{FieldOne: "value one", Extra: Extra{"field_three": []byte(`[1, {"a": "b"}]`)}},
+			&testStruct{FieldOne: "value one", Extra: Extra{"field_three": []byte(`[1.0,{"a":"b"}]`)}},
+			Changes{},
+		},
+		{
+			"should report changed typed fields",
+			testStruct{FieldOne: "value one", FieldTwo: 1.5},
+			testStruct{FieldOne: "value one", FieldTwo: 2},
+			Changes{
+				{Changed, "/field_two", []byte(`1.5`), []byte(`2`)},
+			},
+		},
+		{
+			"should report added, removed and changed extra keys",
+			testStruct{Extra: Extra{"a": []byte(`1`), "b": []byte(`{"c": [1]}`)}},
+			testStruct{Extra: Extra{"b": []byte(`{"c": [2], "d": null}`), "e": []byte(`"e"`)}},
+			Changes{
+				{Removed, "/a", []byte(`1`), nil},
+				{Changed, "/b/c/0", []byte(`1`), []byte(`2`)},
+				{Added, "/b/d", nil, []byte(`null`)},
+				{Added, "/e", nil, []byte(`"e"`)},
+			},
+		},
+		{
+			"should report changes in slices of substructs",
+			testStruct{FieldSubStructs: []subStruct{
+				{"one", Extra{"x": []byte(`1`)}},
+				{"two", Extra{}},
+				{"three", Extra{}},
+			}},
+			testStruct{FieldSubStructs: []subStruct{
+				{"one", Extra{"x": []byte(`2`)}},
+			}},
+			Changes{
+				{Changed, "/field_sub_structs/0/x", []byte(`1`), []byte(`2`)},
+				{Removed, "/field_sub_structs/2", []byte(`{"sub_field_one":"three"}`), nil},
+				{Removed, "/field_sub_structs/1", []byte(`{"sub_field_one":"two"}`), nil},
+			},
+		},
+		{
+			"should report changed values of different kinds",
+			testStruct{Extra: Extra{"a~b": []byte(`{"c": 1}`)}},
+			testStruct{Extra: Extra{"a~b": []byte(`[1]`)}},
+			Changes{
+				{Changed, "/a~0b", []byte(`{"c":1}`), []byte(`[1]`)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			changes, err := Diff(tc.inA, tc.inB)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outChanges, changes)
+
+			// Applying the patch to a should produce b
+			patch, err := changes.Patch()
+			assert.NoError(t, err)
+			var a testStruct
+			encoded, _ := Marshal(tc.inA)
+			assert.NoError(t, Unmarshal(encoded, &a))
+			assert.NoError(t, ApplyPatch(&a, patch))
+			changes, err = Diff(a, tc.inB)
+			assert.NoError(t, err)
+			assert.Equal(t, Changes{}, changes)
+		})
+	}
+}
+
+func TestChangesPatch(t *testing.T) {
+	changes := Changes{
+		{Added, "/a", nil, []byte(`null`)},
+		{Removed, "/b", []byte(`1`), nil},
+		{Changed, "/c", []byte(`1`), []byte(`2`)},
+	}
+	patch, err := changes.Patch()
+	assert.NoError(t, err)
+	var operations []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(patch, &operations))
+	assert.Equal(t, []map[string]interface{}{
+		{"op": "add", "path": "/a", "value": nil},
+		{"op": "remove", "path": "/b"},
+		{"op": "replace", "path": "/c", "value": 2.0},
+	}, operations)
+}
@@ -0,0 +1,115 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Document - A generic wrapper that keeps the extra payload of a value of
+// type T while it is encoded or decoded by the encoding/json package.
+//
+// Document implements json.Marshaler and json.Unmarshaler by delegating to
+// Marshal and Unmarshal, so a value that embeds Extra keeps its extra
+// payload when it is nested in a value that is passed to encoding/json. For
+// a struct type T that does not embed Extra, such as a type from another
+// package, the keys of the JSON object that match no field of T are kept in
+// the Extra field of the Document instead.
+//
+// Marshal, Unmarshal and Schema encode, decode and describe a Document the
+// same way, as the JSON object of its value rather than as a struct with a
+// Value and an Extra field.
+type Document[T any] struct {
+	Value T
+	Extra Extra
+}
+
+// documentValue is implemented by Document, which encodes and decodes
+// itself.
+type documentValue interface {
+	isDocument()
+}
+
+// documentDecoder is implemented by pointers to Document.
+type documentDecoder interface {
+	decodeDocument(data []byte) error
+}
+
+var documentType = reflect.TypeOf((*documentValue)(nil)).Elem()
+
+func (Document[T]) isDocument() {}
+
+// NewDocument returns a Document holding value.
+func NewDocument[T any](value T) Document[T] {
+	return Document[T]{Value: value}
+}
+
+// MarshalJSON returns the JSON encoding of the value, with the extra payload
+// of the Document placed into its JSON object as top-level key/value pairs.
+func (d Document[T]) MarshalJSON() ([]byte, error) {
+	encoded, err := Marshal(d.Value)
+	if err != nil || len(d.Extra) == 0 {
+		return encoded, err
+	}
+
+	var valueAsMap map[string]json.RawMessage
+	err = json.Unmarshal(encoded, &valueAsMap)
+	if err != nil {
+		return nil, &json.UnsupportedValueError{
+			Value: reflect.ValueOf(d.Value),
+			Str:   "extra payload requires a JSON object",
+		}
+	}
+	for key, value := range d.Extra {
+		valueAsMap[key] = value
+	}
+	return json.Marshal(valueAsMap)
+}
+
+// UnmarshalJSON decodes data into the value and stores the keys that match
+// no field of a struct type T without Extra in the Extra of the Document.
+// Extra keys of the value that break the rules registered by
+// RegisterExtraRules are reported as an *ExtraRuleError, as Unmarshal does.
+func (d *Document[T]) UnmarshalJSON(data []byte) error {
+	err := d.decodeDocument(data)
+	if err != nil {
+		return err
+	}
+	return checkExtraRules(data, reflect.TypeOf(&d.Value))
+}
+
+// decodeDocument is UnmarshalJSON without the check of the extra rules, which
+// Unmarshal does once for the whole document.
+func (d *Document[T]) decodeDocument(data []byte) error {
+	var result Document[T]
+	value, err := decodeValue(data, reflect.TypeOf(&result.Value).Elem())
+	if err != nil && asRequiredError(err) == nil {
+		return err
+	}
+	reflect.ValueOf(&result.Value).Elem().Set(value)
+	requiredErr := err
+
+	valueType := value.Type()
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	if isJSONObject(data) && valueType.Kind() == reflect.Struct && !hasExtraStorage(valueType) {
+		var rawMap map[string]json.RawMessage
+		err = json.Unmarshal(data, &rawMap)
+		if err != nil {
+			return err
+		}
+		popMatching(rawMap, valueType)
+		if len(rawMap) > 0 {
+			result.Extra = rawMap
+		}
+	}
+
+	*d = result
+	return requiredErr
+}
+
+// popMatching removes the keys matching a field of structType from rawMap,
+// leaving only the unmatched keys.
+func popMatching(rawMap map[string]json.RawMessage, structType reflect.Type) {
+	popFields(rawMap, structType, func(reflect.StructField, []string, json.RawMessage) {})
+}
@@ -0,0 +1,216 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleDocument() {
+	// A struct type that cannot embed partialmarshal.Extra
+	type thirdpartystruct struct {
+		ExampleFieldOne string
+	}
+
+	// A response envelope that is encoded with encoding/json
+	type envelope struct {
+		Status string                     `json:"status"`
+		Data   Document[thirdpartystruct] `json:"data"`
+	}
+
+	var response envelope
+	err := json.Unmarshal([]byte(`{"status": "ok", "data": {"ExampleFieldOne": "value 1", "some_other_field": 2}}`), &response)
+	fmt.Println(err)
+	fmt.Println(response.Data.Value.ExampleFieldOne)
+	fmt.Printf("%s\n", response.Data.Extra["some_other_field"])
+
+	JSONData, _ := json.Marshal(response)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// value 1
+	// 2
+	// {"status":"ok","data":{"ExampleFieldOne":"value 1","some_other_field":2}}
+}
+
+func TestDocument(t *testing.T) {
+	type structWithExtra struct {
+		FieldOne string `json:"field_one"`
+		Extra
+	}
+	type structWithoutExtra struct {
+		FieldOne string `json:"field_one"`
+		fieldTwo string
+	}
+	type envelope struct {
+		WithExtra    Document[structWithExtra]     `json:"with_extra"`
+		WithoutExtra Document[structWithoutExtra]  `json:"without_extra"`
+		Pointer      Document[*structWithoutExtra] `json:"pointer"`
+		Slice        Document[[]structWithExtra]   `json:"slice"`
+	}
+	testCases := []struct {
+		testDescription string
+		inData          string
+		outEnvelope     envelope
+		outData         string
+	}{
+		{
+			"should keep extra payload of types embedding extra",
+			`{"with_extra": {"field_one": "one", "field_two": 2}}`,
+			envelope{
+				WithExtra: Document[structWithExtra]{Value: structWithExtra{"one", Extra{"field_two": []byte(`2`)}}},
+			},
+			`{"with_extra":{"field_one":"one","field_two":2},"without_extra":{"field_one":""},"pointer":null,"slice":null}`,
+		},
+		{
+			"should keep unknown fields of types without extra in the document",
+			`{"without_extra": {"field_one": "one", "fieldTwo": 2, "field_three": 3}}`,
+			envelope{
+				WithoutExtra: Document[structWithoutExtra]{
+					Value: structWithoutExtra{FieldOne: "one"},
+					Extra: Extra{"fieldTwo": []byte(`2`), "field_three": []byte(`3`)},
+				},
+			},
+			`{"with_extra":{"field_one":""},"without_extra":{"field_one":"one","fieldTwo":2,"field_three":3},"pointer":null,"slice":null}`,
+		},
+		{
+			"should keep unknown fields of pointers to types without extra",
+			`{"pointer": {"field_one": "one", "field_two": 2}}`,
+			envelope{
+				Pointer: Document[*structWithoutExtra]{
+					Value: &structWithoutExtra{FieldOne: "one"},
+					Extra: Extra{"field_two": []byte(`2`)},
+				},
+			},
+			`{"with_extra":{"field_one":""},"without_extra":{"field_one":""},"pointer":{"field_one":"one","field_two":2},"slice":null}`,
+		},
+		{
+			"should keep extra payload of slice elements",
+			`{"slice": [{"field_one": "one", "field_two": 2}]}`,
+			envelope{
+				Slice: Document[[]structWithExtra]{
+					Value: []structWithExtra{{"one", Extra{"field_two": []byte(`2`)}}},
+				},
+			},
+			`{"with_extra":{"field_one":""},"without_extra":{"field_one":""},"pointer":null,"slice":[{"field_one":"one","field_two":2}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var result envelope
+			assert.NoError(t, json.Unmarshal([]byte(tc.inData), &result))
+			assert.Equal(t, tc.outEnvelope, result)
+			encoded, err := json.Marshal(result)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(encoded))
+		})
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+	var document Document[int]
+	assert.EqualError(t, json.Unmarshal([]byte(`"one"`), &document), "json: cannot unmarshal string into Go value of type int")
+
+	document = Document[int]{Value: 1, Extra: Extra{"a": []byte(`1`)}}
+	_, err := document.MarshalJSON()
+	assert.EqualError(t, err, "json: unsupported value: extra payload requires a JSON object")
+
+	encoded, err := json.Marshal(NewDocument(1))
+	assert.NoError(t, err)
+	assert.Equal(t, `1`, string(encoded))
+}
+
+func TestDocumentPartialmarshal(t *testing.T) {
+	type item struct {
+		City string `json:"city"`
+	}
+	type holder struct {
+		Doc  Document[item]   `json:"doc"`
+		Docs []Document[item] `json:"docs"`
+	}
+
+	// Top-level documents are encoded and decoded as the object of their value
+	var document Document[item]
+	err := Unmarshal([]byte(`{"city": "x", "zip": 1}`), &document)
+	assert.NoError(t, err)
+	assert.Equal(t, Document[item]{Value: item{"x"}, Extra: Extra{"zip": []byte(`1`)}}, document)
+	encoded, err := Marshal(document)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"city":"x","zip":1}`, string(encoded))
+
+	// So are documents nested in structs and slices
+	var value holder
+	err = Unmarshal([]byte(`{"doc": {"city": "x", "zip": "1"}, "docs": [{"city": "y", "zip": "2"}]}`), &value)
+	assert.NoError(t, err)
+	assert.Equal(t, holder{
+		Doc:  Document[item]{Value: item{"x"}, Extra: Extra{"zip": []byte(`"1"`)}},
+		Docs: []Document[item]{{Value: item{"y"}, Extra: Extra{"zip": []byte(`"2"`)}}},
+	}, value)
+	encoded, err = Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"doc":{"city":"x","zip":"1"},"docs":[{"city":"y","zip":"2"}]}`, string(encoded))
+
+	// And changes to them are listed by the keys of their object
+	changed := value
+	changed.Doc = Document[item]{Value: item{"z"}, Extra: value.Doc.Extra}
+	changes, err := Diff(value, changed)
+	assert.NoError(t, err)
+	assert.Equal(t, Changes{{Type: Changed, Path: "/doc/city", From: []byte(`"x"`), To: []byte(`"z"`)}}, changes)
+
+	// Schemas describe the object of the value, with the extra keys of the
+	// document
+	schema, err := Schema(holder{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/holder",
+		"$defs": {
+			"holder": {
+				"type": "object",
+				"properties": {
+					"doc": {"$ref": "#/$defs/itemDocument"},
+					"docs": {"anyOf": [{"$ref": "#/$defs/itemDocumentList"}, {"type": "null"}]}
+				},
+				"required": ["doc", "docs"],
+				"additionalProperties": false
+			},
+			"itemDocument": {
+				"type": "object",
+				"properties": {"city": {"type": "string"}},
+				"required": ["city"],
+				"additionalProperties": true
+			},
+			"itemDocumentList": {"type": "array", "items": {"$ref": "#/$defs/itemDocument"}}
+		}
+	}`, string(schema))
+}
+
+func TestDocumentExtraRules(t *testing.T) {
+	type ruledStruct struct {
+		City string `json:"city"`
+		Extra
+	}
+	type holder struct {
+		Doc Document[ruledStruct] `json:"doc"`
+	}
+	assert.NoError(t, RegisterExtraRules(ruledStruct{}, ExtraRule{Pattern: "^x-"}))
+
+	// encoding/json reports the keys that break the rules like Unmarshal does
+	var document Document[ruledStruct]
+	err := json.Unmarshal([]byte(`{"city": "x", "x-a": 1, "zip": 1}`), &document)
+	assert.EqualError(t, err, `partialmarshal: extra keys not allowed: "/zip": key matches no allowed pattern`)
+	assert.Equal(t, "x", document.Value.City)
+	err = Unmarshal([]byte(`{"city": "x", "x-a": 1, "zip": 1}`), &document)
+	assert.EqualError(t, err, `partialmarshal: extra keys not allowed: "/zip": key matches no allowed pattern`)
+
+	// Keys of nested documents are reported by their path in the whole document
+	var value holder
+	err = Unmarshal([]byte(`{"doc": {"city": "x", "zip": 1}}`), &value)
+	assert.EqualError(t, err, `partialmarshal: extra keys not allowed: "/doc/zip": key matches no allowed pattern`)
+	assert.Equal(t, "x", value.Doc.Value.City)
+	assert.NoError(t, json.Unmarshal([]byte(`{"doc": {"city": "x", "x-a": 1}}`), &value))
+}
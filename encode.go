@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/fatih/structs"
 )
@@ -26,7 +27,10 @@ func Marshal(v interface{}) ([]byte, error) {
 
 func marshalArray(v interface{}) ([]byte, error) {
 	slice := reflect.Indirect(reflect.ValueOf(v))
-	var objectArray []json.RawMessage
+	if slice.IsNil() {
+		return json.Marshal(v)
+	}
+	objectArray := make([]json.RawMessage, 0, slice.Len())
 	for i := 0; i < slice.Len(); i++ {
 		obj, err := marshalObject(slice.Index(i).Interface())
 		if err != nil {
@@ -40,23 +44,45 @@ func marshalArray(v interface{}) ([]byte, error) {
 func marshalObject(v interface{}) ([]byte, error) {
 	// 1. Detect and retrieve the partialmarshal.Extra embedded type
 	reflectedValue := reflect.Indirect(reflect.ValueOf(v))
-	if reflectedValue.Kind() != reflect.Struct {
+	if reflectedValue.Kind() != reflect.Struct || reflectedValue.Type().Implements(documentType) {
 		return json.Marshal(v)
 	}
 
-	extraField := reflectedValue.FieldByName("Extra")
-	if !extraField.IsValid() {
+	err := checkTags(reflectedValue.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	extraField := extraFieldOf(reflectedValue)
+	presenceField := reflectedValue.FieldByName("Presence")
+	hasPresence := presenceField.IsValid() && presenceField.Type() == reflect.TypeOf(Presence{})
+	extraOf := extraOfField(reflectedValue)
+	naming := namingFor(reflectedValue.Type())
+	discriminator, isVariant := variantValue(reflectedValue.Type())
+	extensions, err := encodeExtensions(reflectedValue)
+	if err != nil {
+		return nil, err
+	}
+	if !extraField.IsValid() && !extraOf.IsValid() && extensions == nil && !hasPresence && !isVariant && !needsPartialEncoding(reflectedValue.Type()) {
 		return json.Marshal(v)
 	}
+	presence := presenceOf(reflectedValue)
 
 	// 2. Handle any substructs that may or may not have partialmarshal.Extra fields present
-	substructsMap, substructsTagMap := getSubstructsWithExtra(reflectedValue)
+	substructsMap, substructsTagMap, err := getSubstructsWithExtra(reflectedValue)
+	if err != nil {
+		return nil, err
+	}
 
 	// 3. Convert the value v into a map[string]interface{}
 	// https://github.com/fatih/structs/issues/25
 	structs.DefaultTagName = "json"
 	valueAsMap := structs.Map(v)
 	delete(valueAsMap, "Extra")
+	delete(valueAsMap, "ExtraOf")
+	if hasPresence {
+		delete(valueAsMap, "Presence")
+	}
 
 	// 4. Add any found substructs into the map
 	for key, value := range substructsMap {
@@ -69,17 +95,124 @@ func marshalObject(v interface{}) ([]byte, error) {
 		}
 	}
 
-	// 5. Combine the map[string]interface{} v clone with the extra map
-	extraFieldAsMap := extraField.Interface().(Extra)
-	for key, value := range extraFieldAsMap {
-		valueAsMap[key] = value
+	// 5. Move the fields from their json key to their key in the partialmarshal
+	// view, inline the fields of inline structs, and drop the fields that are
+	// skipped, unset Optionals or absent while Presence is tracking them
+	object := make(map[string]interface{}, len(valueAsMap))
+	var nested []nestedValue
+	for i := 0; i < reflectedValue.Type().NumField(); i++ {
+		field := reflectedValue.Type().Field(i)
+		tag, _ := parseFieldTag(field)
+		if field.PkgPath != "" || isStorageField(field) || tag.skip {
+			continue
+		}
+		if tag.inline {
+			err = inlineFields(object, reflectedValue.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		structKey, _ := parseTag(field.Tag.Get("json"))
+		if structKey == "" {
+			structKey = field.Name
+		}
+		value, found := valueAsMap[structKey]
+		if !found && tag.name != "" && structKey == "-" {
+			// Fields left out by encoding/json may still have a key of their own
+			encoded, err := Marshal(reflectedValue.Field(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			value, found = json.RawMessage(encoded), true
+		}
+		name := jsonName(field, naming)
+		optional, isOptional := reflectedValue.Field(i).Interface().(optionalValue)
+		if !found || (presence != nil && !presence.IsSet(name)) || (isOptional && optional.isUnset()) {
+			continue
+		}
+		if path := fieldPath(field, naming); len(path) > 1 {
+			nested = append(nested, nestedValue{path: path, value: value})
+			continue
+		}
+		object[name] = value
+	}
+
+	// 6. Combine the map[string]interface{} v clone with the extra map and the
+	// extensions, or set them under the envelope key of Extra
+	var extraFieldAsMap map[string]json.RawMessage
+	if extraField.IsValid() {
+		extraFieldAsMap = extraField.Interface().(Extra)
+	} else if extraOf.IsValid() {
+		extraFieldAsMap, err = encodeExtraOf(extraOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(extensions) > 0 {
+		// Extensions are written among the extra keys
+		combined := make(map[string]json.RawMessage, len(extraFieldAsMap)+len(extensions))
+		for key, value := range extraFieldAsMap {
+			combined[key] = value
+		}
+		for key, value := range extensions {
+			combined[key] = value
+		}
+		extraFieldAsMap = combined
+	}
+	if key := envelopeKey(reflectedValue.Type()); key != "" {
+		if len(extraFieldAsMap) > 0 {
+			object[key] = extraFieldAsMap
+		}
+	} else {
+		for key, value := range extraFieldAsMap {
+			object[key] = value
+		}
+	}
+
+	// 7. Set the nested keys, merging them into the intermediate objects kept
+	// in the extra map
+	for _, nestedField := range nested {
+		err = setPath(object, nestedField.path, nestedField.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 8. Write the discriminator of variants
+	if isVariant {
+		object[DiscriminatorKey] = discriminator
 	}
 
-	// 6. Encode the combined map into a JSON output
-	return json.Marshal(valueAsMap)
+	// 9. Encode the combined map into a JSON output
+	return json.Marshal(object)
+}
+
+// inlineFields adds the keys of the JSON object of the inline struct or
+// pointer to struct fieldValue to object, where they are not set already.
+func inlineFields(object map[string]interface{}, fieldValue reflect.Value) error {
+	if fieldValue.Kind() == reflect.Ptr && fieldValue.IsNil() {
+		return nil
+	}
+	encoded, err := Marshal(fieldValue.Interface())
+	if err != nil {
+		return err
+	}
+	var inlineMap map[string]json.RawMessage
+	err = json.Unmarshal(encoded, &inlineMap)
+	if err != nil {
+		return err
+	}
+	for key, value := range inlineMap {
+		if _, found := object[key]; !found {
+			object[key] = value
+		}
+	}
+	return nil
 }
 
-func getSubstructsWithExtra(reflectedValue reflect.Value) (map[string]json.RawMessage, map[string]string) {
+func getSubstructsWithExtra(reflectedValue reflect.Value) (map[string]json.RawMessage, map[string]string, error) {
 
 	substructsMap := map[string]json.RawMessage{}
 	substructsTagMap := map[string]string{}
@@ -87,16 +220,49 @@ func getSubstructsWithExtra(reflectedValue reflect.Value) (map[string]json.RawMe
 	for i := 0; i < reflectedValue.Type().NumField(); i++ {
 		structField := reflectedValue.Type().Field(i)
 		field := reflectedValue.Field(i)
-		if field.Type().Kind() == reflect.Struct {
-			encodedStruct, _ := Marshal(field.Interface())
-			jsonTag, _ := parseTag(string(structField.Tag.Get("json")))
+		if field.Type().Kind() == reflect.Struct || isStructSlice(field.Type()) || (isStructPointer(field.Type()) && !field.IsNil()) || (field.Kind() == reflect.Interface && !field.IsNil()) {
+			jsonTag, jsonOptions := parseTag(string(structField.Tag.Get("json")))
+			if jsonTag == "-" || structField.PkgPath != "" {
+				continue
+			}
+			if field.Kind() == reflect.Slice && field.Len() == 0 && strings.Contains(jsonOptions, "omitempty") {
+				continue
+			}
+			encodedStruct, err := Marshal(field.Interface())
+			if err != nil {
+				return nil, nil, err
+			}
 			if jsonTag != "" {
 				substructsTagMap[structField.Name] = jsonTag
 			}
 			substructsMap[structField.Name] = encodedStruct
 		}
 	}
-	return substructsMap, substructsTagMap
+	return substructsMap, substructsTagMap, nil
+}
+
+// jsonName returns the key that field is written under by Marshal, given the
+// naming strategy of its struct type.
+func jsonName(field reflect.StructField, naming NamingStrategy) string {
+	if tag, _ := parseFieldTag(field); tag.name != "" {
+		return tag.name
+	}
+	name, _ := parseTag(field.Tag.Get("json"))
+	if name == "" && naming != nil {
+		return naming(field.Name)
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// isSkipped reports whether field is left out by Marshal, which is when its
+// partialmarshal tag is "-", or its json tag is "-" and its partialmarshal
+// tag has no key.
+func isSkipped(field reflect.StructField) bool {
+	tag, _ := parseFieldTag(field)
+	return tag.skip || (tag.name == "" && field.Tag.Get("json") == "-")
 }
 
 func parseTag(tag string) (string, string) {
@@ -105,3 +271,77 @@ func parseTag(tag string) (string, string) {
 	}
 	return tag, ""
 }
+
+// isStructSlice reports whether t is a slice of structs, of pointers to
+// structs or of interfaces, which may carry their own partialmarshal.Extra.
+func isStructSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && (t.Elem().Kind() == reflect.Struct || isStructPointer(t.Elem()) || t.Elem().Kind() == reflect.Interface)
+}
+
+var partialTypes sync.Map // whether Marshal encodes the type itself, by type
+
+// needsPartialEncoding reports whether values of the struct type t are
+// encoded differently by Marshal than by encoding/json, because of t itself
+// or of the struct types that its fields hold and Marshal descends into, so
+// that they cannot be handed to json.Marshal.
+func needsPartialEncoding(t reflect.Type) bool {
+	if needs, found := partialTypes.Load(t); found {
+		return needs.(bool)
+	}
+	needs := needsPartialEncodingOf(t, map[reflect.Type]bool{})
+	partialTypes.Store(t, needs)
+	return needs
+}
+
+// forgetPartialTypes clears the results of needsPartialEncoding, which
+// depend on the naming strategies and variants registered.
+func forgetPartialTypes() {
+	partialTypes.Range(func(t, _ interface{}) bool {
+		partialTypes.Delete(t)
+		return true
+	})
+}
+
+// needsPartialEncodingOf is needsPartialEncoding for a type that is a field,
+// element or pointee, where seen holds the struct types already visited.
+// Interfaces may hold variants, and types with a MarshalJSON method encode
+// themselves.
+func needsPartialEncodingOf(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return true
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		return false
+	case t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice:
+		return needsPartialEncodingOf(t.Elem(), seen)
+	case t.Kind() != reflect.Struct || seen[t]:
+		return false
+	}
+	seen[t] = true
+
+	if hasExtraStorage(t) || embedsExtensions(t) || hasOptionalFields(t) || hasFieldTags(t) || namingFor(t) != nil {
+		return true
+	}
+	if presenceField, found := t.FieldByName("Presence"); found && presenceField.Type == reflect.TypeOf(Presence{}) {
+		return true
+	}
+	if _, isVariant := variantValue(t); isVariant {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if needsPartialEncodingOf(field.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStructPointer reports whether t is a pointer to a struct, which may carry
+// its own partialmarshal.Extra.
+func isStructPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
@@ -33,6 +33,13 @@ func ExampleMarshal() {
 }
 
 func TestMarshal(t *testing.T) {
+	type subStructWithExtra struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type subStructMalformed struct {
+		SubFieldOne string `partialmarshal:"sub_field_one,unknown"`
+	}
 	testCases := []struct {
 		testDescription string
 		inStruct        interface{}
@@ -131,6 +138,60 @@ func TestMarshal(t *testing.T) {
 			[]byte(`{"field_one":"value one","field_sub_struct":{"sub_field_one":"sub value one","sub_field_two":"sub value two"},"field_two":"value two"}`),
 			"",
 		},
+		{
+			"should marshal slice of substructs fields",
+			&struct {
+				FieldOne        string `json:"field_one"`
+				FieldSubStructs []struct {
+					SubFieldOne string `json:"sub_field_one"`
+					Extra
+				} `json:"field_sub_structs"`
+				Extra
+			}{
+				"value one",
+				[]struct {
+					SubFieldOne string `json:"sub_field_one"`
+					Extra
+				}{
+					{
+						"sub value one",
+						Extra{
+							"sub_field_two": []byte(`"sub value two"`),
+						},
+					},
+				},
+				Extra{
+					"field_two": []byte(`"value two"`),
+				},
+			},
+			[]byte(`{"field_one":"value one","field_sub_structs":[{"sub_field_one":"sub value one","sub_field_two":"sub value two"}],"field_two":"value two"}`),
+			"",
+		},
+		{
+			"should marshal extra field of substruct pointers into their objects",
+			&struct {
+				FieldSubStruct  *subStructWithExtra   `json:"field_sub_struct"`
+				FieldNil        *subStructWithExtra   `json:"field_nil"`
+				FieldSubStructs []*subStructWithExtra `json:"field_sub_structs"`
+				Extra
+			}{
+				&subStructWithExtra{"sub value one", Extra{"sub_field_two": []byte(`2`)}},
+				nil,
+				[]*subStructWithExtra{{"sub value one", Extra{"sub_field_two": []byte(`2`)}}, nil},
+				Extra{},
+			},
+			[]byte(`{"field_nil":null,"field_sub_struct":{"sub_field_one":"sub value one","sub_field_two":2},"field_sub_structs":[{"sub_field_one":"sub value one","sub_field_two":2},null]}`),
+			"",
+		},
+		{
+			"should marshal empty slice pointer into empty array",
+			&[]struct {
+				FieldOne string
+				Extra
+			}{},
+			[]byte(`[]`),
+			"",
+		},
 		// Sad Path Cases
 		{
 			"should return normal encoding when no partialmarshal.Extra embedded type present",
@@ -148,6 +209,26 @@ func TestMarshal(t *testing.T) {
 			[]byte(`""`),
 			"",
 		},
+		{
+			"should return error for malformed tags of substructs",
+			struct {
+				FieldOne struct {
+					FieldSubStruct subStructMalformed `json:"field_sub_struct"`
+				} `json:"field_one"`
+			}{},
+			nil,
+			`partialmarshal: invalid partialmarshal tag on partialmarshal.subStructMalformed.SubFieldOne: unknown option "unknown"`,
+		},
+		{
+			"should return error for malformed tags of substructs held by pointers and interfaces",
+			struct {
+				FieldPointer   *subStructMalformed `json:"field_pointer"`
+				FieldInterface interface{}         `json:"field_interface"`
+				Extra
+			}{FieldInterface: []subStructMalformed{{}}},
+			nil,
+			`partialmarshal: invalid partialmarshal tag on partialmarshal.subStructMalformed.SubFieldOne: unknown option "unknown"`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,59 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// envelopeKey returns the key that the extra storage of the struct type t is
+// written under when it is tagged `partialmarshal:"envelope=key"`, or "" when
+// its keys are written in the object itself.
+func envelopeKey(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isExtraField(field) || isExtraOfField(field) {
+			tag, _ := parseFieldTag(field)
+			return tag.envelope
+		}
+	}
+	return ""
+}
+
+// liftEnvelope moves the keys of the object under the envelope key of rawMap
+// into rawMap itself, where they are not set already. An envelope that is
+// not an object is left as it is.
+func liftEnvelope(rawMap map[string]json.RawMessage, key string) {
+	if key == "" {
+		return
+	}
+	var enveloped map[string]json.RawMessage
+	if !isJSONObject(rawMap[key]) || json.Unmarshal(rawMap[key], &enveloped) != nil {
+		return
+	}
+	delete(rawMap, key)
+	for envelopedKey, rawValue := range enveloped {
+		if _, found := rawMap[envelopedKey]; !found {
+			rawMap[envelopedKey] = rawValue
+		}
+	}
+}
+
+// visitExtra calls visit for each key of rawMap, left by popFields, that
+// Unmarshal keeps in the extra storage of the struct type t, along with the
+// path of the key below path in the document.
+func visitExtra(rawMap map[string]json.RawMessage, t reflect.Type, path []string, visit func(key string, keyPath []string, rawValue json.RawMessage)) {
+	key := envelopeKey(t)
+	var enveloped map[string]json.RawMessage
+	if key != "" && isJSONObject(rawMap[key]) && json.Unmarshal(rawMap[key], &enveloped) == nil {
+		for envelopedKey, rawValue := range enveloped {
+			if _, found := rawMap[envelopedKey]; !found {
+				visit(envelopedKey, append(path[:len(path):len(path)], key, envelopedKey), rawValue)
+			}
+		}
+	}
+	for extraKey, rawValue := range rawMap {
+		if enveloped == nil || extraKey != key {
+			visit(extraKey, append(path[:len(path):len(path)], extraKey), rawValue)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Example_envelope() {
+	// A struct type whose unknown keys are passed along under "_unknown"
+	type examplestruct struct {
+		Name  string `json:"name"`
+		Extra `partialmarshal:"envelope=_unknown"`
+	}
+
+	var destination examplestruct
+	Unmarshal([]byte(`{"name": "gopher", "age": 25, "_unknown": {"color": "blue"}}`), &destination)
+	fmt.Println(string(destination.Extra["age"]), string(destination.Extra["color"]))
+
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// 25 "blue"
+	// {"_unknown":{"age":25,"color":"blue"},"name":"gopher"}
+}
+
+func TestEnvelope(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string       `json:"sub_field_one"`
+		Rest        ExtraOf[int] `partialmarshal:",extra,envelope=rest"`
+	}
+	type testStruct struct {
+		FieldOne   string      `json:"field_one"`
+		AuthorName string      `partialmarshal:"meta.author.name"`
+		Sub        subStruct   `json:"sub"`
+		Subs       []subStruct `json:"subs"`
+		Extra      `partialmarshal:"envelope=_unknown"`
+	}
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+	}{
+		{
+			"should write extra keys under the envelope key at every level",
+			[]byte(`{"field_one": "one", "a": 1, "sub": {"b": 2}, "subs": [{"sub_field_one": "two", "c": 3}]}`),
+			`{"field_one":"one","meta":{"author":{"name":""}},"sub":{"sub_field_one":"","rest":{"b":2}},"subs":[{"sub_field_one":"two","rest":{"c":3}}],"_unknown":{"a":1}}`,
+		},
+		{
+			"should lift the keys of the envelope, keeping the keys of the object first",
+			[]byte(`{"a": 1, "_unknown": {"a": 2, "b": 3}, "sub": {"rest": {"c": 4}}, "subs": null}`),
+			`{"field_one":"","meta":{"author":{"name":""}},"sub":{"sub_field_one":"","rest":{"c":4}},"subs":null,"_unknown":{"a":1,"b":3}}`,
+		},
+		{
+			"should keep envelopes that are not objects as extra keys",
+			[]byte(`{"_unknown": "text", "subs": null}`),
+			`{"field_one":"","meta":{"author":{"name":""}},"sub":{"sub_field_one":""},"subs":null,"_unknown":{"_unknown":"text"}}`,
+		},
+		{
+			"should write the siblings of nested keys under the envelope key",
+			[]byte(`{"meta": {"author": {"name": "Gopher", "id": 7}}, "subs": null}`),
+			`{"field_one":"","meta":{"author":{"name":"Gopher"}},"sub":{"sub_field_one":""},"subs":null,"_unknown":{"meta":{"author":{"id":7}}}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			assert.NoError(t, err)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+
+			// The output decodes back to a value with the same encoding
+			var decoded testStruct
+			err = Unmarshal(result, &decoded)
+			assert.NoError(t, err)
+			roundTrip, err := Marshal(decoded)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(roundTrip))
+		})
+	}
+}
+
+func TestEnvelopeMergePatchAndRules(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra    `partialmarshal:"envelope=_unknown"`
+	}
+	assert.NoError(t, RegisterExtraRules(testStruct{}, ExtraRule{Pattern: "^x-", Kinds: []Kind{KindString}}))
+	defer RegisterExtraRules(testStruct{})
+
+	value := testStruct{Extra: Extra{"x-a": []byte(`"a"`), "x-b": []byte(`"b"`)}}
+	err := MergePatch(&value, []byte(`{"field_one": "one", "_unknown": {"x-a": null, "x-c": "c"}}`))
+	assert.NoError(t, err)
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"one","_unknown":{"x-b":"b","x-c":"c"}}`, string(result))
+
+	err = Unmarshal([]byte(`{"x-a": 1, "_unknown": {"x-a": "a", "b": "b"}}`), &value)
+	assert.EqualError(t, err, `partialmarshal: extra keys not allowed: "/_unknown/b": key matches no allowed pattern, "/x-a": pattern "^x-" does not allow number values`)
+
+	schema, err := Schema(testStruct{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"_unknown":{"additionalProperties":false,"patternProperties":{"^x-":{"type":"string"}},"type":"object"}`)
+}
+
+func TestParseFieldTagEnvelope(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inField         reflect.StructField
+		outEnvelope     string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should parse the envelope of an embedded Extra",
+			reflect.StructField{Name: "Extra", Type: reflect.TypeOf(Extra{}), Anonymous: true, Tag: `partialmarshal:"envelope=_unknown"`},
+			"_unknown",
+			"",
+		},
+		{
+			"should parse the envelope of a field tagged extra",
+			reflect.StructField{Name: "Rest", Type: reflect.TypeOf(ExtraOf[int]{}), Tag: `partialmarshal:",extra,envelope=rest"`},
+			"rest",
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for an empty envelope",
+			reflect.StructField{Name: "Extra", Type: reflect.TypeOf(Extra{}), Anonymous: true, Tag: `partialmarshal:"envelope="`},
+			"",
+			"envelope must not be empty",
+		},
+		{
+			"should return error for an envelope of a field that is not tagged extra",
+			reflect.StructField{Name: "Rest", Type: reflect.TypeOf(Extra{}), Tag: `partialmarshal:"envelope=rest"`},
+			"rest",
+			"envelope requires an embedded Extra or ExtraOf field, or a field tagged extra",
+		},
+		{
+			"should return error for an envelope of other types",
+			reflect.StructField{Name: "Rest", Type: reflect.TypeOf(""), Tag: `partialmarshal:"envelope=rest"`},
+			"rest",
+			"envelope requires an embedded Extra or ExtraOf field, or a field tagged extra",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			tag, err := parseFieldTag(tc.inField)
+			assert.Equal(t, tc.outEnvelope, tag.envelope)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
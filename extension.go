@@ -0,0 +1,206 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Extensions - A type provided for use as an embedded type, next to Extra, to
+// store the values of the extension keys registered by RegisterExtension for
+// the struct, decoded into their registered type.
+type Extensions map[string]interface{}
+
+var (
+	extensionsMutex sync.RWMutex
+	extensionTypes  = map[reflect.Type]map[string]reflect.Type{} // value types by host type and key
+)
+
+// RegisterExtension registers goType, the type of the value goType, as the
+// type of the extension key of the struct type of host, where host is a
+// struct or a pointer to a struct that embeds partialmarshal.Extensions.
+// Registering a nil goType removes the extension.
+//
+// Unmarshal then decodes the key, when it matches no field, into a value of
+// goType stored in the embedded Extensions, while keys of unregistered
+// extensions are kept raw in Extra. Marshal writes the values of Extensions
+// among the extra keys, and MergePatch patches them.
+func RegisterExtension(host interface{}, key string, goType interface{}) error {
+	t := reflect.TypeOf(host)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("partialmarshal: cannot register extensions for %v, which is not a struct", t)
+	}
+	if !embedsExtensions(t) {
+		return fmt.Errorf("partialmarshal: cannot register extensions for %v, which does not embed Extensions", t)
+	}
+
+	extensionsMutex.Lock()
+	defer extensionsMutex.Unlock()
+	if goType == nil {
+		delete(extensionTypes[t], key)
+		return nil
+	}
+	if extensionTypes[t] == nil {
+		extensionTypes[t] = map[string]reflect.Type{}
+	}
+	extensionTypes[t][key] = reflect.TypeOf(goType)
+	return nil
+}
+
+// GetExtension returns the value of the extension key of the struct, or the
+// pointer to a struct, v, when it is set in its embedded Extensions.
+func GetExtension(v interface{}, key string) (interface{}, bool) {
+	reflectedValue := reflect.Indirect(reflect.ValueOf(v))
+	if reflectedValue.Kind() != reflect.Struct {
+		return nil, false
+	}
+	extensions := extensionsFieldOf(reflectedValue)
+	if !extensions.IsValid() {
+		return nil, false
+	}
+	value, found := extensions.Interface().(Extensions)[key]
+	return value, found
+}
+
+// isExtensionsField reports whether field is the embedded
+// partialmarshal.Extensions.
+func isExtensionsField(field reflect.StructField) bool {
+	return field.Anonymous && field.Type == reflect.TypeOf(Extensions{})
+}
+
+// embedsExtensions reports whether the struct type t embeds Extensions.
+func embedsExtensions(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if isExtensionsField(t.Field(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionsFieldOf returns the embedded Extensions of the struct
+// reflectedValue, or an invalid value when there is none.
+func extensionsFieldOf(reflectedValue reflect.Value) reflect.Value {
+	for i := 0; i < reflectedValue.NumField(); i++ {
+		if isExtensionsField(reflectedValue.Type().Field(i)) {
+			return reflectedValue.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// registeredExtensions returns a copy of the extension types registered for
+// the struct type t, by key.
+func registeredExtensions(t reflect.Type) map[string]reflect.Type {
+	extensionsMutex.RLock()
+	defer extensionsMutex.RUnlock()
+	types := make(map[string]reflect.Type, len(extensionTypes[t]))
+	for key, extensionType := range extensionTypes[t] {
+		types[key] = extensionType
+	}
+	return types
+}
+
+// popExtensions removes the registered extension keys of the struct type t
+// from rawMap and calls visit for each of them, in the order of their keys.
+func popExtensions(rawMap map[string]json.RawMessage, t reflect.Type, visit func(key string, extensionType reflect.Type, rawValue json.RawMessage) error) error {
+	types := registeredExtensions(t)
+	keys := make([]string, 0, len(types))
+	for key := range types {
+		if _, found := rawMap[key]; found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rawValue := rawMap[key]
+		delete(rawMap, key)
+		err := visit(key, types[key], rawValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeExtensions decodes the registered extension keys of rawMap into the
+// embedded Extensions of the struct reflectedValue and removes them, and
+// returns the paths of the required keys missing in their values.
+func decodeExtensions(rawMap map[string]json.RawMessage, reflectedValue reflect.Value) ([]string, error) {
+	extensionsField := extensionsFieldOf(reflectedValue)
+	if !extensionsField.IsValid() {
+		return nil, nil
+	}
+	extensions := Extensions{}
+	var missing []string
+	err := popExtensions(rawMap, reflectedValue.Type(), func(key string, extensionType reflect.Type, rawValue json.RawMessage) error {
+		value, err := decodeValue(rawValue, extensionType)
+		if requiredErr := asRequiredError(err); requiredErr != nil {
+			missing = append(missing, requiredErr.pathsBelow(key)...)
+		} else if err != nil {
+			return fmt.Errorf("partialmarshal: invalid extension %q of %v: %v", key, reflectedValue.Type(), err)
+		}
+		extensions[key] = value.Interface()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	extensionsField.Set(reflect.ValueOf(extensions))
+	return missing, nil
+}
+
+// encodeExtensions returns the values of the embedded Extensions of the
+// struct reflectedValue as raw JSON.
+func encodeExtensions(reflectedValue reflect.Value) (map[string]json.RawMessage, error) {
+	extensionsField := extensionsFieldOf(reflectedValue)
+	if !extensionsField.IsValid() {
+		return nil, nil
+	}
+	encoded := map[string]json.RawMessage{}
+	for key, value := range extensionsField.Interface().(Extensions) {
+		rawValue, err := Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		encoded[key] = rawValue
+	}
+	return encoded, nil
+}
+
+// mergePatchExtensions patches the embedded Extensions of the struct
+// reflectedValue with the registered extension keys of patchMap and removes
+// them. A null removes the extension.
+func mergePatchExtensions(patchMap map[string]json.RawMessage, reflectedValue reflect.Value) error {
+	extensionsField := extensionsFieldOf(reflectedValue)
+	if !extensionsField.IsValid() {
+		return nil
+	}
+	return popExtensions(patchMap, reflectedValue.Type(), func(key string, extensionType reflect.Type, rawValue json.RawMessage) error {
+		if extensionsField.IsNil() {
+			extensionsField.Set(reflect.ValueOf(Extensions{}))
+		}
+		extensions := extensionsField.Interface().(Extensions)
+		if isJSONNull(rawValue) {
+			delete(extensions, key)
+			return nil
+		}
+
+		// The current value is patched like a value of any other type
+		value := reflect.New(extensionType).Elem()
+		if current, found := extensions[key]; found && reflect.TypeOf(current) == extensionType {
+			value.Set(reflect.ValueOf(current))
+		}
+		err := mergePatchField(rawValue, value)
+		if err != nil {
+			return err
+		}
+		extensions[key] = value.Interface()
+		return nil
+	})
+}
@@ -0,0 +1,215 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleRegisterExtension() {
+	// A shared document with a license attached by another module
+	type license struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	type document struct {
+		Title string `json:"title"`
+		Extra
+		Extensions
+	}
+	RegisterExtension(document{}, "x-license", license{})
+
+	var destination document
+	Unmarshal([]byte(`{"title": "Gophers", "x-license": {"name": "MIT", "url": "https://opensource.org/licenses/MIT"}, "x-logo": "gopher.png"}`), &destination)
+	value, _ := GetExtension(destination, "x-license")
+	fmt.Printf("%+v\n", value)
+	fmt.Println(string(destination.Extra["x-logo"]))
+
+	destination.Extensions["x-license"] = license{Name: "BSD-3-Clause"}
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// {Name:MIT URL:https://opensource.org/licenses/MIT}
+	// "gopher.png"
+	// {"title":"Gophers","x-license":{"name":"BSD-3-Clause","url":""},"x-logo":"gopher.png"}
+}
+
+func TestRegisterExtension(t *testing.T) {
+	type hostStruct struct {
+		FieldOne string `json:"field_one"`
+		Extensions
+	}
+	type otherStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra
+	}
+
+	testCases := []struct {
+		testDescription string
+		inHost          interface{}
+		inType          interface{}
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should register an extension of a pointed to struct",
+			&hostStruct{},
+			0,
+			"",
+		},
+		{
+			"should remove an extension when registering no type",
+			hostStruct{},
+			nil,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for a host that is not a struct",
+			"",
+			0,
+			"partialmarshal: cannot register extensions for string, which is not a struct",
+		},
+		{
+			"should return error for a host that does not embed Extensions",
+			otherStruct{},
+			0,
+			"partialmarshal: cannot register extensions for partialmarshal.otherStruct, which does not embed Extensions",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := RegisterExtension(tc.inHost, "x-count", tc.inType)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUnmarshalExtensions(t *testing.T) {
+	type logoStruct struct {
+		URL string `json:"url" partialmarshal:"required"`
+		Extra
+	}
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+		Extensions
+	}
+	type testStruct struct {
+		FieldOne string      `json:"field_one"`
+		Subs     []subStruct `json:"subs"`
+		Extra
+		Extensions
+	}
+	assert.NoError(t, RegisterExtension(testStruct{}, "x-logo", &logoStruct{}))
+	assert.NoError(t, RegisterExtension(testStruct{}, "x-count", 0))
+	assert.NoError(t, RegisterExtension(testStruct{}, "field_one", 0))
+	assert.NoError(t, RegisterExtension(subStruct{}, "x-tags", []string{}))
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outExtensions   Extensions
+		outExtra        Extra
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should decode registered extensions and keep other keys in Extra",
+			[]byte(`{"field_one": "one", "x-logo": {"url": "gopher.png", "width": 10}, "x-count": 2, "x-other": true, "subs": [{"x-tags": ["a"]}]}`),
+			Extensions{"x-logo": &logoStruct{URL: "gopher.png", Extra: Extra{"width": []byte(`10`)}}, "x-count": 2},
+			Extra{"x-other": []byte(`true`)},
+			`{"field_one":"one","x-logo":{"url":"gopher.png","width":10},"x-count":2,"x-other":true,"subs":[{"sub_field_one":"","x-tags":["a"]}]}`,
+			"",
+		},
+		{
+			"should decode no extensions",
+			[]byte(`{"field_one": "one", "subs": null}`),
+			Extensions{},
+			Extra{},
+			`{"field_one":"one","subs":null}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for missing required keys of extensions",
+			[]byte(`{"x-logo": {}, "subs": [{"x-tags": []}]}`),
+			nil,
+			nil,
+			``,
+			`partialmarshal: missing required keys: "/x-logo/url"`,
+		},
+		{
+			"should return error for extensions of another type",
+			[]byte(`{"x-count": "two"}`),
+			nil,
+			nil,
+			``,
+			`partialmarshal: invalid extension "x-count" of partialmarshal.testStruct: json: cannot unmarshal string into Go value of type int`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outExtensions, value.Extensions)
+			assert.Equal(t, tc.outExtra, value.Extra)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra
+		Extensions
+	}
+	assert.NoError(t, RegisterExtension(testStruct{}, "x-tags", []string{}))
+	assert.NoError(t, RegisterExtension(testStruct{}, "x-meta", map[string]int{}))
+
+	// GetExtension reads values and pointers
+	value := testStruct{Extensions: Extensions{"x-tags": []string{"a"}}}
+	tags, found := GetExtension(&value, "x-tags")
+	assert.True(t, found)
+	assert.Equal(t, []string{"a"}, tags)
+	_, found = GetExtension(value, "x-meta")
+	assert.False(t, found)
+	_, found = GetExtension(1, "x-meta")
+	assert.False(t, found)
+
+	// MergePatch patches extensions like values of their type
+	err := MergePatch(&value, []byte(`{"x-tags": null, "x-meta": {"a": 1}, "x-other": 2}`))
+	assert.NoError(t, err)
+	err = MergePatch(&value, []byte(`{"x-meta": {"b": 2}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Extensions{"x-meta": map[string]int{"a": 1, "b": 2}}, value.Extensions)
+	assert.Equal(t, Extra{"x-other": []byte(`2`)}, value.Extra)
+
+	// Extensions are known keys and described by the schema
+	unknowns, err := UnmarshalWithUnknowns([]byte(`{"value": {"x-meta": {"a": 1}}}`), &struct {
+		Value testStruct `json:"value"`
+	}{})
+	assert.NoError(t, err)
+	assert.Empty(t, unknowns)
+	schema, err := Schema(testStruct{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"x-tags":{"anyOf":[{"$ref":"#/$defs/`)
+	assert.NotContains(t, string(schema), `"Extensions"`)
+}
@@ -0,0 +1,201 @@
+package partialmarshal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind - The kind of a JSON value, named like the JSON Schema types.
+type Kind string
+
+// The kinds of JSON values.
+const (
+	KindNull    Kind = "null"
+	KindBoolean Kind = "boolean"
+	KindNumber  Kind = "number"
+	KindString  Kind = "string"
+	KindArray   Kind = "array"
+	KindObject  Kind = "object"
+)
+
+// ExtraRule allows the extra keys of a struct type that match the regular
+// expression Pattern, with values of one of Kinds, or of any kind when Kinds
+// is empty.
+type ExtraRule struct {
+	Pattern string
+	Kinds   []Kind
+}
+
+// extraRule is an ExtraRule with its pattern compiled.
+type extraRule struct {
+	pattern *regexp.Regexp
+	kinds   []Kind
+}
+
+var (
+	extraRulesMutex sync.RWMutex
+	extraRules      = map[reflect.Type][]extraRule{}
+)
+
+// RegisterExtraRules sets the rules that the extra payload of the struct type
+// of v must follow, where v is a struct or a pointer to a struct that embeds
+// Extra or ExtraOf.
+//
+// Every extra key must match the pattern of at least one rule, and its value
+// must be of a kind allowed by every rule whose pattern it matches. Unmarshal
+// and UnmarshalAs report the extra keys that break the rules, at any nesting
+// depth, as an *ExtraRuleError. Registering the rules of a type again
+// replaces them, and registering no rules removes them.
+//
+// Schema describes the rules as patternProperties along with
+// additionalProperties false, so that the schema, like Unmarshal, rejects
+// the extra keys that match no pattern, while the keys of fields and
+// extensions are still allowed as properties.
+func RegisterExtraRules(v interface{}, rules ...ExtraRule) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || !hasExtraStorage(t) {
+		return fmt.Errorf("partialmarshal: cannot register extra rules for %v, which does not embed Extra or ExtraOf", t)
+	}
+
+	compiled := make([]extraRule, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("partialmarshal: invalid extra rule pattern %q: %v", rule.Pattern, err)
+		}
+		for _, kind := range rule.Kinds {
+			switch kind {
+			case KindNull, KindBoolean, KindNumber, KindString, KindArray, KindObject:
+			default:
+				return fmt.Errorf("partialmarshal: invalid extra rule kind %q", kind)
+			}
+		}
+		compiled = append(compiled, extraRule{pattern: pattern, kinds: rule.Kinds})
+	}
+
+	extraRulesMutex.Lock()
+	defer extraRulesMutex.Unlock()
+	if len(compiled) == 0 {
+		delete(extraRules, t)
+	} else {
+		extraRules[t] = compiled
+	}
+	return nil
+}
+
+// registeredExtraRules returns the rules registered for the struct type t.
+func registeredExtraRules(t reflect.Type) []extraRule {
+	extraRulesMutex.RLock()
+	defer extraRulesMutex.RUnlock()
+	return extraRules[t]
+}
+
+// ExtraRuleError describes extra keys that break the rules registered for
+// their struct type, keyed by their JSON Pointer in the whole document.
+//
+// The value is still decoded when this error is returned.
+type ExtraRuleError struct {
+	Errors map[string]error
+}
+
+func (e *ExtraRuleError) Error() string {
+	pointers := make([]string, 0, len(e.Errors))
+	for pointer := range e.Errors {
+		pointers = append(pointers, pointer)
+	}
+	sort.Strings(pointers)
+	messages := make([]string, 0, len(pointers))
+	for _, pointer := range pointers {
+		messages = append(messages, fmt.Sprintf("%q: %s", pointer, e.Errors[pointer]))
+	}
+	return "partialmarshal: extra keys not allowed: " + strings.Join(messages, ", ")
+}
+
+// checkExtraRules returns an *ExtraRuleError for the extra keys of data,
+// decoded into a value of type t, that break the registered rules.
+func checkExtraRules(data json.RawMessage, t reflect.Type) error {
+	extraRulesMutex.RLock()
+	empty := len(extraRules) == 0
+	extraRulesMutex.RUnlock()
+	if empty || t == nil {
+		// A nil v accepts any JSON value, like it does for encoding/json
+		return nil
+	}
+
+	ruleErrors := map[string]error{}
+	walkObjects(data, t, []string{}, func(t reflect.Type, rawMap map[string]json.RawMessage, path []string) {
+		rules := registeredExtraRules(t)
+		if len(rules) == 0 {
+			return
+		}
+		visitExtra(rawMap, t, path, func(key string, keyPath []string, rawValue json.RawMessage) {
+			if err := checkExtraKey(rules, key, rawValue); err != nil {
+				ruleErrors[formatPointer(keyPath)] = err
+			}
+		})
+	})
+	if len(ruleErrors) > 0 {
+		return &ExtraRuleError{Errors: ruleErrors}
+	}
+	return nil
+}
+
+// checkExtraKey returns why the extra key with rawValue breaks rules, or nil.
+func checkExtraKey(rules []extraRule, key string, rawValue json.RawMessage) error {
+	kind := kindOf(rawValue)
+	matched := false
+	for _, rule := range rules {
+		if !rule.pattern.MatchString(key) {
+			continue
+		}
+		matched = true
+		if len(rule.kinds) > 0 && !containsKind(rule.kinds, kind) {
+			return fmt.Errorf("pattern %q does not allow %s values", rule.pattern, kind)
+		}
+	}
+	if !matched {
+		return errors.New("key matches no allowed pattern")
+	}
+	return nil
+}
+
+// kindOf returns the kind of the JSON value rawValue.
+func kindOf(rawValue json.RawMessage) Kind {
+	rawValue = bytes.TrimSpace(rawValue)
+	if len(rawValue) == 0 {
+		return KindNull
+	}
+	switch rawValue[0] {
+	case 'n':
+		return KindNull
+	case 't', 'f':
+		return KindBoolean
+	case '"':
+		return KindString
+	case '[':
+		return KindArray
+	case '{':
+		return KindObject
+	}
+	return KindNumber
+}
+
+// containsKind reports whether kinds contains kind.
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleRegisterExtraRules() {
+	// A struct type whose extra keys must be vendor extensions
+	type apispec struct {
+		Title string `json:"title"`
+		Extra
+	}
+	RegisterExtraRules(apispec{}, ExtraRule{Pattern: "^x-", Kinds: []Kind{KindString, KindObject}})
+
+	var destination apispec
+	err := Unmarshal([]byte(`{"title": "pets", "x-owner": "gophers", "x-ttl": 3, "version": "1"}`), &destination)
+	fmt.Println(err)
+
+	// Output:
+	// partialmarshal: extra keys not allowed: "/version": key matches no allowed pattern, "/x-ttl": pattern "^x-" does not allow number values
+}
+
+func TestRegisterExtraRules(t *testing.T) {
+	type plainStruct struct {
+		FieldOne string `json:"field_one"`
+	}
+	type extraStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+		inRules         []ExtraRule
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should register rules for a pointer to a struct with Extra",
+			&extraStruct{},
+			[]ExtraRule{{Pattern: "^a", Kinds: []Kind{KindNull}}},
+			"",
+		},
+		{
+			"should remove rules when registering none",
+			extraStruct{},
+			nil,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for a struct without extra storage",
+			plainStruct{},
+			[]ExtraRule{{Pattern: "^a"}},
+			"partialmarshal: cannot register extra rules for partialmarshal.plainStruct, which does not embed Extra or ExtraOf",
+		},
+		{
+			"should return error for nil",
+			nil,
+			[]ExtraRule{{Pattern: "^a"}},
+			"partialmarshal: cannot register extra rules for <nil>, which does not embed Extra or ExtraOf",
+		},
+		{
+			"should return error for an invalid pattern",
+			extraStruct{},
+			[]ExtraRule{{Pattern: "(a"}},
+			"partialmarshal: invalid extra rule pattern \"(a\": error parsing regexp: missing closing ): `(a`",
+		},
+		{
+			"should return error for an invalid kind",
+			extraStruct{},
+			[]ExtraRule{{Pattern: "^a", Kinds: []Kind{"integer"}}},
+			"partialmarshal: invalid extra rule kind \"integer\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := RegisterExtraRules(tc.inValue, tc.inRules...)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+	assert.Nil(t, registeredExtraRules(reflect.TypeOf(extraStruct{})))
+}
+
+func TestUnmarshalExtraRules(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		ExtraOf[int]
+	}
+	type testStruct struct {
+		FieldOne        string               `json:"field_one"`
+		FieldSubStruct  subStruct            `json:"field_sub_struct"`
+		FieldSubStructs []subStruct          `json:"field_sub_structs"`
+		FieldMap        map[string]subStruct `json:"field_map"`
+		FieldOpt        Optional[subStruct]  `json:"field_opt"`
+		Extra
+	}
+	assert.NoError(t, RegisterExtraRules(testStruct{},
+		ExtraRule{Pattern: "^x-"},
+		ExtraRule{Pattern: "^x-num-", Kinds: []Kind{KindNumber, KindNull}},
+	))
+	assert.NoError(t, RegisterExtraRules(subStruct{}, ExtraRule{Pattern: "^[a-z]+$"}))
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should accept extra keys that follow the rules",
+			[]byte(`{"field_one": "one", "x-a": {"b": 1}, "x-num-c": null, "field_sub_struct": {"abc": 1}}`),
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should report keys that match no pattern",
+			[]byte(`{"field_one": "one", "y": 1}`),
+			`partialmarshal: extra keys not allowed: "/y": key matches no allowed pattern`,
+		},
+		{
+			"should report values of kinds that a matching rule does not allow",
+			[]byte(`{"x-num-a": "1", "x-num-b": [], "x-num-c": 1.5}`),
+			`partialmarshal: extra keys not allowed: "/x-num-a": pattern "^x-num-" does not allow string values, "/x-num-b": pattern "^x-num-" does not allow array values`,
+		},
+		{
+			"should report nested keys by their JSON Pointer",
+			[]byte(`{
+				"field_sub_struct": {"sub_field_one": "one", "A": 1},
+				"field_sub_structs": [{"ok": 1}, {"a/b": 2}],
+				"field_map": {"key": {"B_": 3}},
+				"field_opt": {"C": 4}
+			}`),
+			`partialmarshal: extra keys not allowed: "/field_map/key/B_": key matches no allowed pattern, "/field_opt/C": key matches no allowed pattern, "/field_sub_struct/A": key matches no allowed pattern, "/field_sub_structs/1/a~1b": key matches no allowed pattern`,
+		},
+		{
+			"should return decoding errors before rule errors",
+			[]byte(`{"y": 1, "field_sub_struct": {"abc": "one"}}`),
+			`partialmarshal: cannot decode extra values: "abc": json: cannot unmarshal string into Go value of type int`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			_, err = UnmarshalAs[[]testStruct]([]byte("[" + string(tc.inData) + "]"))
+			if tc.outErrMsg != "" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// The value is still decoded
+	var value testStruct
+	err := Unmarshal([]byte(`{"field_one": "one", "y": 1}`), &value)
+	assert.IsType(t, &ExtraRuleError{}, err)
+	assert.Equal(t, "one", value.FieldOne)
+	assert.Equal(t, `1`, string(value.Extra["y"]))
+
+	// A nil value still accepts any JSON value while rules are registered
+	assert.NoError(t, Unmarshal([]byte(`1`), nil))
+}
+
+func TestSchemaExtraRules(t *testing.T) {
+	type extraStruct struct {
+		Extra
+	}
+	type extraOfStruct struct {
+		ExtraOf[string]
+	}
+	assert.NoError(t, RegisterExtraRules(extraStruct{},
+		ExtraRule{Pattern: "^x-"},
+		ExtraRule{Pattern: "^y-", Kinds: []Kind{KindString}},
+		ExtraRule{Pattern: "^y-", Kinds: []Kind{KindString, KindNull}},
+	))
+	assert.NoError(t, RegisterExtraRules(extraOfStruct{}, ExtraRule{Pattern: "^x-", Kinds: []Kind{KindString}}))
+
+	result, err := Schema(extraStruct{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/extraStruct",
+		"$defs": {"extraStruct": {"type": "object", "properties": {}, "additionalProperties": false, "patternProperties": {
+			"^x-": {},
+			"^y-": {"allOf": [{"type": "string"}, {"type": ["string", "null"]}]}
+		}}}
+	}`, string(result))
+
+	result, err = Schema(extraOfStruct{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/extraOfStruct",
+		"$defs": {"extraOfStruct": {"type": "object", "properties": {}, "additionalProperties": false, "patternProperties": {
+			"^x-": {"allOf": [{"type": "string"}, {"type": "string"}]}
+		}}}
+	}`, string(result))
+}
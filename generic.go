@@ -0,0 +1,159 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnmarshalAs parses the JSON-encoded data and returns the result as a new
+// value of type T, keeping extra payloads and checking the extra rules like
+// Unmarshal does. Pointers to structs are decoded through the struct, and
+// the value is returned along with an error like Unmarshal leaves it in v.
+func UnmarshalAs[T any](data []byte) (T, error) {
+	var v T
+	target := reflect.ValueOf(&v)
+	if t := target.Elem().Type(); isStructPointer(t) && !t.Implements(unmarshalerType) && !isJSONNull(data) {
+		target.Elem().Set(reflect.New(t.Elem()))
+		target = target.Elem()
+	}
+
+	// Types that decode themselves and values other than structs, slices and
+	// variants are left to encoding/json, since Unmarshal only decodes JSON
+	// objects into structs
+	t := target.Elem().Type()
+	if reflect.PtrTo(t).Implements(unmarshalerType) || (t.Kind() != reflect.Struct && t.Kind() != reflect.Slice && !hasVariants(t)) {
+		return v, json.Unmarshal(data, target.Interface())
+	}
+	return v, Unmarshal(data, target.Interface())
+}
+
+// MarshalSlice returns the JSON encoding of the slice s, placing the extra
+// payload of each element into its JSON object like Marshal does.
+func MarshalSlice[T any](s []T) ([]byte, error) {
+	return Marshal(s)
+}
+
+// ExtraOf - A generic type provided for use as an embedded type to indicate
+// a storage location for extra payloads, like Extra, whose values are
+// decoded into V instead of being kept as raw JSON.
+//
+// Extra values that cannot be decoded into V are reported by Unmarshal as an
+// *ExtraTypeError, while the other values are still stored.
+type ExtraOf[V any] map[string]V
+
+func (ExtraOf[V]) isExtraOf() {}
+
+// extraOfMap is implemented by every ExtraOf type.
+type extraOfMap interface {
+	isExtraOf()
+}
+
+var extraOfMapType = reflect.TypeOf((*extraOfMap)(nil)).Elem()
+
+// ExtraTypeError describes extra values that could not be decoded into the
+// value type of an embedded ExtraOf, keyed by their JSON key.
+type ExtraTypeError struct {
+	Errors map[string]error
+}
+
+func (e *ExtraTypeError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for key := range e.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, fmt.Sprintf("%q: %s", key, e.Errors[key]))
+	}
+	return "partialmarshal: cannot decode extra values: " + strings.Join(messages, ", ")
+}
+
+// isExtraOfField reports whether field is an embedded partialmarshal.ExtraOf,
+// or a field of an ExtraOf type tagged `partialmarshal:",extra"`.
+func isExtraOfField(field reflect.StructField) bool {
+	return field.Type.Implements(extraOfMapType) && (field.Anonymous || isTaggedExtra(field))
+}
+
+// extraOfField returns the embedded ExtraOf of the struct reflectedValue,
+// or an invalid value when there is none.
+func extraOfField(reflectedValue reflect.Value) reflect.Value {
+	for i := 0; i < reflectedValue.NumField(); i++ {
+		if isExtraOfField(reflectedValue.Type().Field(i)) {
+			return reflectedValue.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// decodeExtraOf decodes every value of rawMap into the ExtraOf field.
+func decodeExtraOf(rawMap map[string]json.RawMessage, field reflect.Value) error {
+	extraMap := reflect.MakeMapWithSize(field.Type(), len(rawMap))
+	typeErrors := map[string]error{}
+	for key, rawValue := range rawMap {
+		value, err := decodeValue(rawValue, field.Type().Elem())
+		if err != nil {
+			typeErrors[key] = err
+			continue
+		}
+		extraMap.SetMapIndex(reflect.ValueOf(key), value)
+	}
+	field.Set(extraMap)
+	if len(typeErrors) > 0 {
+		return &ExtraTypeError{Errors: typeErrors}
+	}
+	return nil
+}
+
+// mergePatchExtraOf merges the keys of patchMap into the ExtraOf field.
+func mergePatchExtraOf(patchMap map[string]json.RawMessage, field reflect.Value) error {
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+	typeErrors := map[string]error{}
+	for key, rawValue := range patchMap {
+		if isJSONNull(rawValue) {
+			field.SetMapIndex(reflect.ValueOf(key), reflect.Value{})
+			continue
+		}
+		var current json.RawMessage
+		if currentValue := field.MapIndex(reflect.ValueOf(key)); currentValue.IsValid() {
+			var err error
+			current, err = Marshal(currentValue.Interface())
+			if err != nil {
+				return err
+			}
+		}
+		merged, err := mergeRaw(current, rawValue)
+		if err != nil {
+			return err
+		}
+		value, err := decodeValue(merged, field.Type().Elem())
+		if err != nil {
+			typeErrors[key] = err
+			continue
+		}
+		field.SetMapIndex(reflect.ValueOf(key), value)
+	}
+	if len(typeErrors) > 0 {
+		return &ExtraTypeError{Errors: typeErrors}
+	}
+	return nil
+}
+
+// encodeExtraOf returns the values of the ExtraOf field as raw JSON.
+func encodeExtraOf(field reflect.Value) (map[string]json.RawMessage, error) {
+	rawMap := make(map[string]json.RawMessage, field.Len())
+	iterator := field.MapRange()
+	for iterator.Next() {
+		encoded, err := Marshal(iterator.Value().Interface())
+		if err != nil {
+			return nil, err
+		}
+		rawMap[iterator.Key().String()] = encoded
+	}
+	return rawMap, nil
+}
@@ -0,0 +1,184 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleUnmarshalAs() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		Extra
+	}
+
+	destination, err := UnmarshalAs[examplestruct]([]byte(`{"ExampleFieldOne": "value 1", "some_other_field": 2}`))
+	fmt.Println(err)
+	fmt.Println(destination.ExampleFieldOne)
+	fmt.Printf("%s", destination.Extra["some_other_field"])
+
+	// Output:
+	// <nil>
+	// value 1
+	// 2
+}
+
+func ExampleExtraOf() {
+	// A metrics struct whose unknown keys are all numbers
+	type metrics struct {
+		Host string `json:"host"`
+		ExtraOf[float64]
+	}
+
+	destination, err := UnmarshalAs[metrics]([]byte(`{"host": "gopher", "cpu": 0.5, "memory": 512}`))
+	fmt.Println(err)
+	fmt.Println(destination.ExtraOf["cpu"] + destination.ExtraOf["memory"])
+
+	// Output:
+	// <nil>
+	// 512.5
+}
+
+func TestUnmarshalAs(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra
+	}
+
+	value, err := UnmarshalAs[testStruct]([]byte(`{"field_one": "value one", "field_two": 2}`))
+	assert.NoError(t, err)
+	assert.Equal(t, testStruct{"value one", Extra{"field_two": []byte(`2`)}}, value)
+
+	values, err := UnmarshalAs[[]testStruct]([]byte(`[{"field_one": "value one"}, {"field_two": 2}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []testStruct{{"value one", Extra{}}, {"", Extra{"field_two": []byte(`2`)}}}, values)
+
+	mapping, err := UnmarshalAs[map[string]int]([]byte(`{"a": 1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, mapping)
+
+	pointer, err := UnmarshalAs[*testStruct]([]byte(`{"field_one": "value one", "field_two": 2}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &testStruct{"value one", Extra{"field_two": []byte(`2`)}}, pointer)
+
+	pointer, err = UnmarshalAs[*testStruct]([]byte(`null`))
+	assert.NoError(t, err)
+	assert.Nil(t, pointer)
+
+	pointers, err := UnmarshalAs[[]*testStruct]([]byte(`[{"field_one": "value one"}, null, {"field_two": 2}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []*testStruct{{"value one", Extra{}}, nil, {"", Extra{"field_two": []byte(`2`)}}}, pointers)
+
+	_, err = UnmarshalAs[testStruct]([]byte(`{"field_one": 1}`))
+	assert.EqualError(t, err, "json: cannot unmarshal number into Go value of type string")
+
+	// The value is returned along with an error like Unmarshal leaves it
+	type floatStruct struct {
+		Host string `json:"host"`
+		ExtraOf[float64]
+	}
+	data := []byte(`{"host": "h", "a": 1, "b": "two"}`)
+	var expected floatStruct
+	expectedErr := Unmarshal(data, &expected)
+	floats, err := UnmarshalAs[floatStruct](data)
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, floatStruct{"h", ExtraOf[float64]{"a": 1}}, floats)
+	assert.Equal(t, expected, floats)
+}
+
+func TestMarshalSlice(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		Extra
+	}
+
+	result, err := MarshalSlice([]testStruct{{"value one", Extra{"field_two": []byte(`2`)}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"field_one":"value one","field_two":2}]`, string(result))
+
+	result, err = MarshalSlice([]testStruct(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, `null`, string(result))
+}
+
+func TestExtraOf(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type floatStruct struct {
+		FieldOne string `json:"field_one"`
+		ExtraOf[float64]
+	}
+	type subStructStruct struct {
+		FieldOne string `json:"field_one"`
+		ExtraOf[subStruct]
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		inStruct        interface{}
+		outStruct       interface{}
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should decode extra values into value type",
+			[]byte(`{"field_one": "value one", "a": 1.5, "b": 2}`),
+			&floatStruct{},
+			&floatStruct{"value one", ExtraOf[float64]{"a": 1.5, "b": 2}},
+			`{"field_one":"value one","a":1.5,"b":2}`,
+			"",
+		},
+		{
+			"should decode extra values into structs keeping their extra",
+			[]byte(`{"a": {"sub_field_one": "one", "sub_field_two": "two"}}`),
+			&subStructStruct{},
+			&subStructStruct{"", ExtraOf[subStruct]{"a": {"one", Extra{"sub_field_two": []byte(`"two"`)}}}},
+			`{"field_one":"","a":{"sub_field_one":"one","sub_field_two":"two"}}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should report type errors per key and keep valid values",
+			[]byte(`{"a": 1, "b": "two", "c": [3]}`),
+			&floatStruct{},
+			&floatStruct{"", ExtraOf[float64]{"a": 1}},
+			``,
+			`partialmarshal: cannot decode extra values: "b": json: cannot unmarshal string into Go value of type float64, "c": json: cannot unmarshal array into Go value of type float64`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := Unmarshal(tc.inData, tc.inStruct)
+			assert.Equal(t, tc.outStruct, tc.inStruct)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				assert.IsType(t, &ExtraTypeError{}, err)
+				return
+			}
+			assert.NoError(t, err)
+			result, err := Marshal(tc.inStruct)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestExtraOfMergePatch(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		ExtraOf[map[string]int]
+	}
+	value := testStruct{"value one", ExtraOf[map[string]int]{"a": {"x": 1}, "b": {"y": 2}}}
+
+	assert.NoError(t, MergePatch(&value, []byte(`{"a": {"z": 3}, "b": null, "c": {}}`)))
+	assert.Equal(t, testStruct{"value one", ExtraOf[map[string]int]{"a": {"x": 1, "z": 3}, "c": {}}}, value)
+
+	err := MergePatch(&value, []byte(`{"a": "one"}`))
+	assert.EqualError(t, err, `partialmarshal: cannot decode extra values: "a": json: cannot unmarshal string into Go value of type map[string]int`)
+}
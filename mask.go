@@ -0,0 +1,189 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maskNode is one level of a parsed field mask. A node without children
+// selects the whole value.
+type maskNode map[string]maskNode
+
+// MarshalMask returns the JSON encoding of v restricted to the paths listed
+// in mask, such as "name" or "address.city".
+//
+// Path segments are matched against the JSON names that Marshal writes,
+// and a segment of "*" matches every key at its level. Paths apply to every
+// element of slices, and segments that do not name a field of a struct with
+// partialmarshal.Extra select the matching Extra key. A path that cannot
+// exist in the type of v, because it names an unknown key of a struct
+// without Extra or continues past a scalar field, is reported as an error.
+func MarshalMask(v interface{}, mask []string) ([]byte, error) {
+	// 1. Parse the mask into a tree of path segments
+	tree := maskNode{}
+	for _, path := range mask {
+		segments := strings.Split(path, ".")
+		node := tree
+		for i, segment := range segments {
+			if segment == "" {
+				return nil, fmt.Errorf("partialmarshal: invalid mask path %q: empty segment", path)
+			}
+			// 2. Check the path against the type of v
+			err := checkMaskSegment(reflect.TypeOf(v), segments[:i+1])
+			if err != nil {
+				return nil, fmt.Errorf("partialmarshal: invalid mask path %q: %s", path, err)
+			}
+			child, found := node[segment]
+			if found && child == nil {
+				// A shorter path already selects the whole value
+				break
+			}
+			if i == len(segments)-1 {
+				node[segment] = nil
+				break
+			}
+			if child == nil {
+				child = maskNode{}
+				node[segment] = child
+			}
+			node = child
+		}
+	}
+
+	// 3. Project the encoded value onto the mask
+	document, err := marshalDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(applyMask(document, tree))
+}
+
+// checkMaskSegment checks that the last of segments can follow the ones
+// before it in a value of type t.
+func checkMaskSegment(t reflect.Type, segments []string) error {
+	for i, segment := range segments {
+		t = maskElemType(t)
+		if t == nil {
+			// Raw JSON, such as Extra content, cannot be checked
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+			if segment == "*" {
+				t = nil
+				continue
+			}
+			field, found := fieldByJSONName(t, segment)
+			if found {
+				t = field.Type
+				continue
+			}
+			if _, intermediate := intermediatePaths(t, pathNode{})[segment]; intermediate || hasExtraStorage(t) {
+				// Intermediate objects of nested keys are not checked further
+				t = nil
+				continue
+			}
+			return fmt.Errorf("no field %q", strings.Join(segments[:i+1], "."))
+		case reflect.Interface:
+			t = nil
+			continue
+		}
+		return fmt.Errorf("%q has no fields", strings.Join(segments[:i], "."))
+	}
+	return nil
+}
+
+// maskElemType returns the type whose fields a mask segment selects within
+// a value of type t, looking through pointers and slices. It returns nil for
+// unknown types and for types that encode themselves.
+func maskElemType(t reflect.Type) reflect.Type {
+	for t != nil {
+		if t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) {
+			return nil
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+	return nil
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// fieldByJSONName returns the exported field of the struct type t that
+// Marshal writes under name.
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	naming := namingFor(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case field.PkgPath != "" || isSkipped(field) || isStorageField(field):
+			continue
+		case isInlineField(field):
+			if inlined, found := fieldByJSONName(inlineType(field.Type), name); found {
+				return inlined, true
+			}
+		case jsonName(field, naming) == name:
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func applyMask(document interface{}, tree maskNode) interface{} {
+	switch document := document.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, value := range document {
+			child, found := tree[key]
+			wildcard, wildcardFound := tree["*"]
+			if !found && !wildcardFound {
+				continue
+			}
+			if found && wildcardFound {
+				child = mergeMaskNodes(child, wildcard)
+			} else if wildcardFound {
+				child = wildcard
+			}
+			if child == nil {
+				result[key] = value
+			} else if masked := applyMask(value, child); masked != nil {
+				result[key] = masked
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(document))
+		for i, value := range document {
+			result[i] = applyMask(value, tree)
+		}
+		return result
+	}
+	// A path that continues past a scalar selects nothing
+	return nil
+}
+
+// mergeMaskNodes combines two mask nodes selecting the same value.
+func mergeMaskNodes(a, b maskNode) maskNode {
+	if a == nil || b == nil {
+		return nil
+	}
+	result := maskNode{}
+	for _, node := range []maskNode{a, b} {
+		for key, child := range node {
+			if existing, found := result[key]; found {
+				child = mergeMaskNodes(existing, child)
+			}
+			result[key] = child
+		}
+	}
+	return result
+}
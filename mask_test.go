@@ -0,0 +1,168 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleMarshalMask() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type address struct {
+		Street string `json:"street"`
+		City   string `json:"city"`
+	}
+	type examplestruct struct {
+		Name    string  `json:"name"`
+		Address address `json:"address"`
+		Extra
+	}
+
+	source := examplestruct{
+		"gopher",
+		address{"Main Street", "Gopher City"},
+		Extra{
+			"age":    []byte(`25`),
+			"height": []byte(`180`),
+		},
+	}
+
+	JSONData, err := MarshalMask(source, []string{"name", "address.city", "age"})
+	fmt.Println(err)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// {"address":{"city":"Gopher City"},"age":25,"name":"gopher"}
+}
+
+func TestMarshalMask(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		SubFieldTwo string `json:"sub_field_two"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string               `json:"field_one"`
+		FieldSubStruct  subStruct            `json:"field_sub_struct"`
+		FieldSubStructs []subStruct          `json:"field_sub_structs"`
+		FieldMap        map[string]subStruct `json:"field_map"`
+		Extra
+	}
+	type testStructWithoutExtra struct {
+		FieldOne string `json:"field_one"`
+		FieldTwo string `json:"field_two"`
+	}
+	value := &testStruct{
+		"value one",
+		subStruct{"sub one", "sub two", Extra{"sub_field_three": []byte(`{"a":1,"b":2}`)}},
+		[]subStruct{
+			{"first one", "first two", Extra{}},
+			{"second one", "second two", Extra{"sub_field_three": []byte(`3`)}},
+		},
+		map[string]subStruct{
+			"key": {"map one", "map two", Extra{}},
+		},
+		Extra{"field_two": []byte(`{"c":[{"d":1,"e":2}]}`)},
+	}
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+		inMask          []string
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should output typed fields",
+			value,
+			[]string{"field_one"},
+			`{"field_one":"value one"}`,
+			"",
+		},
+		{
+			"should output nested typed fields",
+			value,
+			[]string{"field_sub_struct.sub_field_two", "field_map.key.sub_field_one"},
+			`{"field_sub_struct":{"sub_field_two":"sub two"},"field_map":{"key":{"sub_field_one":"map one"}}}`,
+			"",
+		},
+		{
+			"should output fields of every element of slices of substructs",
+			value,
+			[]string{"field_sub_structs.sub_field_one", "field_sub_structs.sub_field_three"},
+			`{"field_sub_structs":[{"sub_field_one":"first one"},{"sub_field_one":"second one","sub_field_three":3}]}`,
+			"",
+		},
+		{
+			"should output extra keys and their content",
+			value,
+			[]string{"field_two.c.e", "field_sub_struct.sub_field_three.b", "missing"},
+			`{"field_two":{"c":[{"e":2}]},"field_sub_struct":{"sub_field_three":{"b":2}}}`,
+			"",
+		},
+		{
+			"should output every key matching wildcards",
+			value,
+			[]string{"field_sub_struct.*", "field_map.*.sub_field_two"},
+			`{"field_sub_struct":{"sub_field_one":"sub one","sub_field_two":"sub two","sub_field_three":{"a":1,"b":2}},"field_map":{"key":{"sub_field_two":"map two"}}}`,
+			"",
+		},
+		{
+			"should combine wildcards with named keys",
+			value,
+			[]string{"*.sub_field_one", "field_sub_struct.sub_field_two"},
+			`{"field_sub_struct":{"sub_field_one":"sub one","sub_field_two":"sub two"},"field_sub_structs":[{"sub_field_one":"first one"},{"sub_field_one":"second one"}],"field_map":{},"field_two":{}}`,
+			"",
+		},
+		{
+			"should output whole value when shorter path is present",
+			value,
+			[]string{"field_sub_struct.sub_field_one", "field_sub_struct"},
+			`{"field_sub_struct":{"sub_field_one":"sub one","sub_field_two":"sub two","sub_field_three":{"a":1,"b":2}}}`,
+			"",
+		},
+		{
+			"should output elements of top-level slices",
+			[]testStructWithoutExtra{{"one", "two"}, {"three", "four"}},
+			[]string{"field_two"},
+			`[{"field_two":"two"},{"field_two":"four"}]`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for unknown key of struct without extra",
+			testStructWithoutExtra{},
+			[]string{"field_three"},
+			``,
+			`partialmarshal: invalid mask path "field_three": no field "field_three"`,
+		},
+		{
+			"should return error for path past a scalar field",
+			value,
+			[]string{"field_sub_struct.sub_field_one.a"},
+			``,
+			`partialmarshal: invalid mask path "field_sub_struct.sub_field_one.a": "field_sub_struct.sub_field_one" has no fields`,
+		},
+		{
+			"should return error for empty segment",
+			value,
+			[]string{"field_sub_struct..a"},
+			``,
+			`partialmarshal: invalid mask path "field_sub_struct..a": empty segment`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			result, err := MarshalMask(tc.inValue, tc.inMask)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.JSONEq(t, tc.outData, string(result))
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Conflict describes a path that was changed differently by both sides of
+// a three-way merge. Base, Ours and Theirs hold the JSON encoding of the
+// value at Path in each version, or nil where it is absent.
+type Conflict struct {
+	Path   string
+	Base   json.RawMessage
+	Ours   json.RawMessage
+	Theirs json.RawMessage
+}
+
+// absentMember marks a key that is absent from one of the merged documents.
+var absentMember = &struct{}{}
+
+// Merge3 combines the changes that ours and theirs each made to base.
+//
+// The values are merged by their JSON encoding as produced by Marshal, so
+// changes to typed fields and to partialmarshal.Extra keys are combined
+// alike, key by key and, for arrays of equal length, element by element.
+// When both sides changed the same path differently, a Conflict is reported
+// for that path and the merged value keeps the change from ours.
+//
+// The merged value is returned as a pointer to a new value of the type of
+// ours, which must not be nil.
+func Merge3(base, ours, theirs interface{}) (interface{}, []Conflict, error) {
+	oursType := reflect.TypeOf(ours)
+	if oursType == nil {
+		return nil, nil, errors.New("partialmarshal: Merge3 called with a nil ours")
+	}
+	if oursType.Kind() == reflect.Ptr {
+		oursType = oursType.Elem()
+	}
+
+	documents := make([]interface{}, 3)
+	for i, v := range []interface{}{base, ours, theirs} {
+		var err error
+		documents[i], err = marshalDocument(v)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	conflicts := []Conflict{}
+	mergedDocument, err := merge3Documents(documents[0], documents[1], documents[2], []string{}, &conflicts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged := reflect.New(oursType)
+	err = unmarshalDocument(mergedDocument, merged.Interface())
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged.Interface(), conflicts, nil
+}
+
+func merge3Documents(base, ours, theirs interface{}, path []string, conflicts *[]Conflict) (interface{}, error) {
+	switch {
+	case documentsEqual(ours, theirs):
+		return ours, nil
+	case documentsEqual(base, ours):
+		return theirs, nil
+	case documentsEqual(base, theirs):
+		return ours, nil
+	}
+
+	// Both sides changed the value, merge recursively when possible.
+	oursObject, oursIsObject := ours.(map[string]interface{})
+	theirsObject, theirsIsObject := theirs.(map[string]interface{})
+	if oursIsObject && theirsIsObject {
+		baseObject, ok := base.(map[string]interface{})
+		if !ok {
+			baseObject = map[string]interface{}{}
+		}
+		return merge3Objects(baseObject, oursObject, theirsObject, path, conflicts)
+	}
+	baseArray, baseIsArray := base.([]interface{})
+	oursArray, oursIsArray := ours.([]interface{})
+	theirsArray, theirsIsArray := theirs.([]interface{})
+	if baseIsArray && oursIsArray && theirsIsArray && len(baseArray) == len(oursArray) && len(oursArray) == len(theirsArray) {
+		merged := make([]interface{}, len(oursArray))
+		for i := range merged {
+			var err error
+			merged[i], err = merge3Documents(baseArray[i], oursArray[i], theirsArray[i], append(path[:len(path):len(path)], strconv.Itoa(i)), conflicts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return merged, nil
+	}
+
+	conflict := Conflict{Path: formatPointer(path)}
+	for _, member := range []struct {
+		document interface{}
+		raw      *json.RawMessage
+	}{{base, &conflict.Base}, {ours, &conflict.Ours}, {theirs, &conflict.Theirs}} {
+		if member.document == absentMember {
+			continue
+		}
+		encoded, err := json.Marshal(member.document)
+		if err != nil {
+			return nil, err
+		}
+		*member.raw = encoded
+	}
+	*conflicts = append(*conflicts, conflict)
+	return ours, nil
+}
+
+func merge3Objects(base, ours, theirs map[string]interface{}, path []string, conflicts *[]Conflict) (interface{}, error) {
+	keySet := map[string]bool{}
+	for _, object := range []map[string]interface{}{base, ours, theirs} {
+		for key := range object {
+			keySet[key] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	merged := map[string]interface{}{}
+	for _, key := range keys {
+		value, err := merge3Documents(memberOrMissing(base, key), memberOrMissing(ours, key), memberOrMissing(theirs, key), append(path[:len(path):len(path)], key), conflicts)
+		if err != nil {
+			return nil, err
+		}
+		if value != absentMember {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
+
+func memberOrMissing(object map[string]interface{}, key string) interface{} {
+	value, found := object[key]
+	if !found {
+		return absentMember
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleMerge3() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo string `json:"example_field_two"`
+		Extra
+	}
+
+	base := examplestruct{"Value 1", "Value 2", Extra{}}
+	// One service only knows ExampleFieldOne and changes it...
+	ours := examplestruct{"Our value 1", "Value 2", Extra{}}
+	// ...while another one adds a field the first service does not know.
+	theirs := examplestruct{"Value 1", "Value 2", Extra{"some_other_field": []byte(`"some other value"`)}}
+
+	merged, conflicts, err := Merge3(base, ours, theirs)
+	fmt.Println(err)
+	fmt.Println(len(conflicts))
+	fmt.Println(merged.(*examplestruct).ExampleFieldOne)
+	fmt.Printf("%s", merged.(*examplestruct).Extra["some_other_field"])
+
+	// Output:
+	// <nil>
+	// 0
+	// Our value 1
+	// "some other value"
+}
+
+func TestMerge3(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string      `json:"field_one"`
+		FieldTwo        int         `json:"field_two"`
+		FieldSubStructs []subStruct `json:"field_sub_structs"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inBase          interface{}
+		inOurs          interface{}
+		inTheirs        interface{}
+		outMerged       interface{}
+		outConflicts    []Conflict
+	}{
+		{
+			"should combine changes to different typed fields",
+			testStruct{FieldOne: "one", FieldTwo: 2, Extra: Extra{}},
+			testStruct{FieldOne: "our one", FieldTwo: 2, Extra: Extra{}},
+			&testStruct{FieldOne: "one", FieldTwo: 3, Extra: Extra{}},
+			&testStruct{FieldOne: "our one", FieldTwo: 3, Extra: Extra{}},
+			[]Conflict{},
+		},
+		{
+			"should combine changes to extra keys",
+			testStruct{Extra: Extra{"a": []byte(`1`), "b": []byte(`{"c": 1, "d": 1}`)}},
+			testStruct{Extra: Extra{"b": []byte(`{"c": 2, "d": 1}`)}},
+			testStruct{Extra: Extra{"a": []byte(`1`), "b": []byte(`{"c": 1}`), "e": []byte(`true`)}},
+			&testStruct{Extra: Extra{"b": []byte(`{"c":2}`), "e": []byte(`true`)}},
+			[]Conflict{},
+		},
+		{
+			"should accept identical changes on both sides",
+			testStruct{FieldOne: "one", Extra: Extra{}},
+			testStruct{FieldOne: "same", Extra: Extra{"a": []byte(`1`)}},
+			testStruct{FieldOne: "same", Extra: Extra{"a": []byte(`1.0`)}},
+			&testStruct{FieldOne: "same", Extra: Extra{"a": []byte(`1`)}},
+			[]Conflict{},
+		},
+		{
+			"should merge slices of substructs element by element",
+			testStruct{FieldSubStructs: []subStruct{{"one", Extra{}}, {"two", Extra{}}}, Extra: Extra{}},
+			testStruct{FieldSubStructs: []subStruct{{"our one", Extra{}}, {"two", Extra{}}}, Extra: Extra{}},
+			testStruct{FieldSubStructs: []subStruct{{"one", Extra{}}, {"two", Extra{"x": []byte(`1`)}}}, Extra: Extra{}},
+			&testStruct{FieldSubStructs: []subStruct{{"our one", Extra{}}, {"two", Extra{"x": []byte(`1`)}}}, Extra: Extra{}},
+			[]Conflict{},
+		},
+		// Conflicts
+		{
+			"should report conflicting changes to the same typed field",
+			testStruct{FieldOne: "one", FieldTwo: 2, Extra: Extra{}},
+			testStruct{FieldOne: "our one", FieldTwo: 2, Extra: Extra{}},
+			testStruct{FieldOne: "their one", FieldTwo: 3, Extra: Extra{}},
+			&testStruct{FieldOne: "our one", FieldTwo: 3, Extra: Extra{}},
+			[]Conflict{
+				{"/field_one", []byte(`"one"`), []byte(`"our one"`), []byte(`"their one"`)},
+			},
+		},
+		{
+			"should report removal on one side and change on the other",
+			testStruct{Extra: Extra{"a": []byte(`{"b": 1}`)}},
+			testStruct{Extra: Extra{}},
+			testStruct{Extra: Extra{"a": []byte(`{"b": 2}`)}},
+			&testStruct{Extra: Extra{}},
+			[]Conflict{
+				{"/a", []byte(`{"b":1}`), nil, []byte(`{"b":2}`)},
+			},
+		},
+		{
+			"should report keys added differently on both sides by nested path",
+			testStruct{Extra: Extra{}},
+			testStruct{Extra: Extra{"a": []byte(`{"b": 1, "c": 1}`)}},
+			testStruct{Extra: Extra{"a": []byte(`{"b": 2, "d": 1}`)}},
+			&testStruct{Extra: Extra{"a": []byte(`{"b":1,"c":1,"d":1}`)}},
+			[]Conflict{
+				{"/a/b", nil, []byte(`1`), []byte(`2`)},
+			},
+		},
+		{
+			"should report slices of different length as a whole",
+			testStruct{FieldSubStructs: []subStruct{{"one", Extra{}}}, Extra: Extra{}},
+			testStruct{FieldSubStructs: []subStruct{}, Extra: Extra{}},
+			testStruct{FieldSubStructs: []subStruct{{"one", Extra{}}, {"two", Extra{}}}, Extra: Extra{}},
+			&testStruct{FieldSubStructs: []subStruct{}, Extra: Extra{}},
+			[]Conflict{
+				{"/field_sub_structs", []byte(`[{"sub_field_one":"one"}]`), []byte(`[]`), []byte(`[{"sub_field_one":"one"},{"sub_field_one":"two"}]`)},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			merged, conflicts, err := Merge3(tc.inBase, tc.inOurs, tc.inTheirs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outMerged, merged)
+			assert.Equal(t, tc.outConflicts, conflicts)
+		})
+	}
+}
+
+func TestMerge3Errors(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+	}
+
+	_, _, err := Merge3(nil, nil, nil)
+	assert.EqualError(t, err, "partialmarshal: Merge3 called with a nil ours")
+
+	// A nil pointer still has a type to merge into
+	merged, conflicts, err := Merge3(nil, (*testStruct)(nil), testStruct{"one"})
+	assert.NoError(t, err)
+	assert.Equal(t, &testStruct{"one"}, merged)
+	assert.Equal(t, []Conflict{}, conflicts)
+}
@@ -0,0 +1,214 @@
+package partialmarshal
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MergePatch applies the RFC 7396 JSON Merge Patch document patch to the
+// value pointed to by v.
+//
+// Keys in the patch that match a field of v replace the value of that field,
+// and patches for substructs are applied recursively. Keys that do not match
+// a field are merged into the embedded partialmarshal.Extra of the struct
+// they belong to. A null in the patch resets a matching field to its zero
+// value or removes the key from Extra.
+func MergePatch(v interface{}, patch []byte) error {
+	reflectedValue, err := getReflectedValue(v)
+	if err != nil {
+		return err
+	}
+	return mergePatchObject(patch, reflectedValue)
+}
+
+func mergePatchObject(patch json.RawMessage, reflectedValue reflect.Value) error {
+	// 1. A patch that is not an object replaces the target entirely.
+	if !isJSONObject(patch) {
+		if isJSONNull(patch) {
+			reflectedValue.Set(reflect.Zero(reflectedValue.Type()))
+			return nil
+		}
+		actualValue, err := decodeValue(patch, reflectedValue.Type())
+		if err != nil {
+			return err
+		}
+		reflectedValue.Set(actualValue)
+		return nil
+	}
+
+	var patchMap map[string]json.RawMessage
+	err := json.Unmarshal(patch, &patchMap)
+	if err != nil {
+		return err
+	}
+	err = checkTags(reflectedValue.Type())
+	if err != nil {
+		return err
+	}
+
+	// 2. Patch the matching fields, leaving only the unmatched keys in patchMap.
+	err = mergePatchFields(patchMap, reflectedValue)
+	if err != nil {
+		return err
+	}
+
+	// 3. Patch the registered extensions and merge the other unmatched keys
+	// into the Extra nested struct, lifting the keys patched under its
+	// envelope key first
+	liftEnvelope(patchMap, envelopeKey(reflectedValue.Type()))
+	err = mergePatchExtensions(patchMap, reflectedValue)
+	if err != nil {
+		return err
+	}
+	extraField := extraFieldOf(reflectedValue)
+	if extraOf := extraOfField(reflectedValue); !extraField.IsValid() && extraOf.IsValid() && len(patchMap) > 0 {
+		return mergePatchExtraOf(patchMap, extraOf)
+	}
+	if !extraField.IsValid() || len(patchMap) == 0 {
+		return nil
+	}
+	if extraField.IsNil() {
+		extraField.Set(reflect.ValueOf(Extra{}))
+	}
+	extra := extraField.Interface().(Extra)
+	for key, rawValue := range patchMap {
+		if isJSONNull(rawValue) {
+			delete(extra, key)
+			continue
+		}
+		merged, err := mergeRaw(extra[key], rawValue)
+		if err != nil {
+			return err
+		}
+		extra[key] = merged
+	}
+	return nil
+}
+
+// mergePatchFields patches the fields of the struct reflectedValue, including
+// the fields of inline structs, with the matching keys of patchMap and
+// removes those keys.
+func mergePatchFields(patchMap map[string]json.RawMessage, reflectedValue reflect.Value) error {
+	naming := namingFor(reflectedValue.Type())
+	for i := 0; i < reflectedValue.Type().NumField(); i++ {
+		field := reflectedValue.Type().Field(i)
+		if isStorageField(field) || field.PkgPath != "" {
+			continue
+		}
+		tag, _ := parseFieldTag(field)
+		if tag.skip {
+			continue
+		}
+		if tag.inline {
+			fieldValue := reflectedValue.Field(i)
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			err := mergePatchFields(patchMap, fieldValue)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		rawValue, found := popValueByField(patchMap, field, naming)
+		if !found {
+			continue
+		}
+		err := mergePatchField(rawValue, reflectedValue.Field(i))
+		if err != nil {
+			return err
+		}
+		if presence := presenceOf(reflectedValue); presence != nil {
+			// A null removes the field, so it is no longer present.
+			presence[jsonName(field, naming)] = !isJSONNull(rawValue)
+		}
+	}
+	return nil
+}
+
+func mergePatchField(rawValue json.RawMessage, fieldValue reflect.Value) error {
+	if isJSONNull(rawValue) {
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		return nil
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Struct:
+		if !reflect.PtrTo(fieldValue.Type()).Implements(unmarshalerType) {
+			return mergePatchObject(rawValue, fieldValue)
+		}
+	case reflect.Ptr:
+		if fieldValue.Type().Elem().Kind() == reflect.Struct && isJSONObject(rawValue) && !fieldValue.Type().Implements(unmarshalerType) {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			return mergePatchObject(rawValue, fieldValue.Elem())
+		}
+	case reflect.Map:
+		if isJSONObject(rawValue) {
+			// Maps are patched as generic JSON objects.
+			current, err := json.Marshal(fieldValue.Interface())
+			if err != nil {
+				return err
+			}
+			rawValue, err = mergeRaw(current, rawValue)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	actualValue, err := decodeValue(rawValue, fieldValue.Type())
+	if err != nil {
+		return err
+	}
+	fieldValue.Set(actualValue)
+	return nil
+}
+
+// mergeRaw applies the merge patch to the raw JSON target and returns the
+// patched document, as described by RFC 7396 section 2.
+func mergeRaw(target, patch json.RawMessage) (json.RawMessage, error) {
+	if !isJSONObject(patch) {
+		return patch, nil
+	}
+
+	var patchMap map[string]json.RawMessage
+	err := json.Unmarshal(patch, &patchMap)
+	if err != nil {
+		return nil, err
+	}
+
+	targetMap := map[string]json.RawMessage{}
+	if isJSONObject(target) {
+		err = json.Unmarshal(target, &targetMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range patchMap {
+		if isJSONNull(value) {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key], err = mergeRaw(targetMap[key], value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(targetMap)
+}
+
+func isJSONObject(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
@@ -0,0 +1,262 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleMergePatch() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo string `json:"example_field_two"`
+		Extra
+	}
+
+	destination := examplestruct{
+		"Value 1",
+		"Value 2",
+		Extra{
+			"some_other_field": []byte(`"some other value"`),
+		},
+	}
+
+	// Update a known field, add an extra key and remove another one.
+	err := MergePatch(&destination, []byte(`{
+		"example_field_two": "new value 2",
+		"some_other_field": null,
+		"some_new_field": 3
+	}`))
+	fmt.Println(err)
+	fmt.Println(destination.ExampleFieldTwo)
+	fmt.Println(len(destination.Extra))
+	fmt.Printf("%s", destination.Extra["some_new_field"])
+
+	// Output:
+	// <nil>
+	// new value 2
+	// 1
+	// 3
+}
+
+func TestMergePatch(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne       string            `json:"field_one"`
+		FieldTwo       int               `json:"field_two"`
+		FieldSubStruct subStruct         `json:"field_sub_struct"`
+		FieldMap       map[string]string `json:"field_map"`
+		FieldPointer   *subStruct        `json:"field_pointer"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inStruct        interface{}
+		inPatch         []byte
+		outStruct       interface{}
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should replace matching fields and keep others",
+			&testStruct{FieldOne: "value one", FieldTwo: 2},
+			[]byte(`{"field_one": "new value one"}`),
+			&testStruct{FieldOne: "new value one", FieldTwo: 2},
+			"",
+		},
+		{
+			"should reset matching fields to zero value on null",
+			&testStruct{FieldOne: "value one", FieldTwo: 2},
+			[]byte(`{"field_two": null}`),
+			&testStruct{FieldOne: "value one"},
+			"",
+		},
+		{
+			"should add unmatched keys to extra",
+			&testStruct{FieldOne: "value one"},
+			[]byte(`{"field_three": "value three"}`),
+			&testStruct{
+				FieldOne: "value one",
+				Extra: Extra{
+					"field_three": []byte(`"value three"`),
+				},
+			},
+			"",
+		},
+		{
+			"should remove extra keys on null",
+			&testStruct{
+				FieldOne: "value one",
+				Extra: Extra{
+					"field_three": []byte(`"value three"`),
+					"field_four":  []byte(`"value four"`),
+				},
+			},
+			[]byte(`{"field_three": null}`),
+			&testStruct{
+				FieldOne: "value one",
+				Extra: Extra{
+					"field_four": []byte(`"value four"`),
+				},
+			},
+			"",
+		},
+		{
+			"should merge objects stored in extra recursively",
+			&testStruct{
+				Extra: Extra{
+					"field_three": []byte(`{"a":1,"b":2}`),
+				},
+			},
+			[]byte(`{"field_three": {"b": null, "c": {"d": 4, "e": null}}}`),
+			&testStruct{
+				Extra: Extra{
+					"field_three": []byte(`{"a":1,"c":{"d":4}}`),
+				},
+			},
+			"",
+		},
+		{
+			"should patch substructs and their extra",
+			&testStruct{
+				FieldSubStruct: subStruct{
+					"sub value one",
+					Extra{
+						"sub_field_two": []byte(`"sub value two"`),
+					},
+				},
+			},
+			[]byte(`{"field_sub_struct": {"sub_field_two": null, "sub_field_three": true}}`),
+			&testStruct{
+				FieldSubStruct: subStruct{
+					"sub value one",
+					Extra{
+						"sub_field_three": []byte(`true`),
+					},
+				},
+			},
+			"",
+		},
+		{
+			"should allocate and patch pointers to substructs",
+			&testStruct{},
+			[]byte(`{"field_pointer": {"sub_field_one": "sub value one", "sub_field_two": "sub value two"}}`),
+			&testStruct{
+				FieldPointer: &subStruct{
+					"sub value one",
+					Extra{
+						"sub_field_two": []byte(`"sub value two"`),
+					},
+				},
+			},
+			"",
+		},
+		{
+			"should merge map fields",
+			&testStruct{FieldMap: map[string]string{"a": "1", "b": "2"}},
+			[]byte(`{"field_map": {"a": null, "c": "3"}}`),
+			&testStruct{FieldMap: map[string]string{"b": "2", "c": "3"}},
+			"",
+		},
+		{
+			"should ignore unmatched keys when extra not embedded",
+			&struct {
+				FieldOne string `json:"field_one"`
+			}{"value one"},
+			[]byte(`{"field_one": "new value one", "field_two": "value two"}`),
+			&struct {
+				FieldOne string `json:"field_one"`
+			}{"new value one"},
+			"",
+		},
+		{
+			"should keep keys matching unexported fields in extra",
+			&struct {
+				FieldOne string `json:"field_one"`
+				secret   string
+				Extra
+			}{FieldOne: "value one"},
+			[]byte(`{"secret": "value two"}`),
+			&struct {
+				FieldOne string `json:"field_one"`
+				secret   string
+				Extra
+			}{FieldOne: "value one", Extra: Extra{"secret": []byte(`"value two"`)}},
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when provided value not struct pointer",
+			testStruct{},
+			[]byte(`{}`),
+			testStruct{},
+			"json: Unmarshal(non-pointer partialmarshal.testStruct)",
+		},
+		{
+			"should return error when patch value has wrong type",
+			&testStruct{},
+			[]byte(`{"field_two": "two"}`),
+			&testStruct{},
+			"json: cannot unmarshal string into Go value of type int",
+		},
+		{
+			"should return error when provided with malformed JSON",
+			&testStruct{},
+			[]byte(`{decidedly not json in format`),
+			&testStruct{},
+			"invalid character 'd' looking for beginning of object key string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := MergePatch(tc.inStruct, tc.inPatch)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.outStruct, tc.inStruct)
+			}
+		})
+	}
+}
+
+func TestMergeRaw(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inTarget        json.RawMessage
+		inPatch         json.RawMessage
+		outResult       string
+	}{
+		// Test cases from RFC 7396 Appendix A
+		{"should replace member", []byte(`{"a":"b"}`), []byte(`{"a":"c"}`), `{"a":"c"}`},
+		{"should add member", []byte(`{"a":"b"}`), []byte(`{"b":"c"}`), `{"a":"b","b":"c"}`},
+		{"should remove member", []byte(`{"a":"b"}`), []byte(`{"a":null}`), `{}`},
+		{"should remove one of many members", []byte(`{"a":"b","b":"c"}`), []byte(`{"a":null}`), `{"b":"c"}`},
+		{"should replace array", []byte(`{"a":["b"]}`), []byte(`{"a":"c"}`), `{"a":"c"}`},
+		{"should replace with array", []byte(`{"a":"c"}`), []byte(`{"a":["b"]}`), `{"a":["b"]}`},
+		{"should merge nested", []byte(`{"a":{"b":"c"}}`), []byte(`{"a":{"b":"d","c":null}}`), `{"a":{"b":"d"}}`},
+		{"should replace array of objects", []byte(`{"a":[{"b":"c"}]}`), []byte(`{"a":[1]}`), `{"a":[1]}`},
+		{"should replace document with array", []byte(`["a","b"]`), []byte(`["c","d"]`), `["c","d"]`},
+		{"should replace object with array", []byte(`{"a":"b"}`), []byte(`["c"]`), `["c"]`},
+		{"should replace with null", []byte(`{"a":"foo"}`), []byte(`null`), `null`},
+		{"should replace with scalar", []byte(`{"a":"foo"}`), []byte(`"bar"`), `"bar"`},
+		{"should keep existing null", []byte(`{"e":null}`), []byte(`{"a":1}`), `{"a":1,"e":null}`},
+		{"should replace array with object", []byte(`[1,2]`), []byte(`{"a":"b","c":null}`), `{"a":"b"}`},
+		{"should strip nulls from new objects", []byte(`{}`), []byte(`{"a":{"bb":{"ccc":null}}}`), `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			result, err := mergeRaw(tc.inTarget, tc.inPatch)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outResult, string(result))
+		})
+	}
+}
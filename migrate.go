@@ -0,0 +1,174 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Migration - A step between two consecutive versions of the JSON objects of
+// a struct type.
+//
+// Up transforms an object of version From into an object of version From+1,
+// for example by moving extra keys into the key of a new field, renaming
+// keys or splitting values. Down does the reverse, and may be left nil when
+// documents are never written for older versions. Both modify the object in
+// place, and the version key is updated after them.
+type Migration struct {
+	From int
+	Up   func(object map[string]json.RawMessage) error
+	Down func(object map[string]json.RawMessage) error
+}
+
+// migrations holds the migrations registered for a struct type.
+type migrations struct {
+	versionKey string
+	steps      map[int]Migration // by version migrated from
+	first      int               // oldest version that can be migrated
+	current    int               // version that the struct type decodes
+}
+
+var (
+	migrationsMutex sync.RWMutex
+	typeMigrations  = map[reflect.Type]*migrations{}
+)
+
+// RegisterMigrations sets the migrations between the versions of the JSON
+// objects of the struct type of v, where v is a struct or a pointer to a
+// struct. The version of an object is the integer under versionKey, or 0
+// when the key is missing, and the current version is the one after the
+// last migration.
+//
+// Unmarshal runs the Up steps on the JSON objects of the type, at any
+// nesting depth, before matching them against its fields, so that they are
+// decoded at the current version. MarshalVersion runs the Down steps to
+// write a value for older consumers. Registering the migrations of a type
+// again replaces them, and registering no migrations removes them. Objects
+// decoded by an UnmarshalJSON method of their own type are left as they are.
+func RegisterMigrations(v interface{}, versionKey string, steps ...Migration) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("partialmarshal: cannot register migrations for %v, which is not a struct", t)
+	}
+	if len(steps) == 0 {
+		migrationsMutex.Lock()
+		defer migrationsMutex.Unlock()
+		delete(typeMigrations, t)
+		return nil
+	}
+
+	// 1. Check that the steps migrate between consecutive versions
+	sorted := append([]Migration{}, steps...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+	registered := &migrations{
+		versionKey: versionKey,
+		steps:      map[int]Migration{},
+		first:      sorted[0].From,
+		current:    sorted[len(sorted)-1].From + 1,
+	}
+	for i, step := range sorted {
+		if step.Up == nil {
+			return fmt.Errorf("partialmarshal: migration of %v from version %d has no Up step", t, step.From)
+		}
+		if i > 0 && step.From != sorted[i-1].From+1 {
+			return fmt.Errorf("partialmarshal: migrations of %v skip or repeat version %d", t, sorted[i-1].From+1)
+		}
+		registered.steps[step.From] = step
+	}
+
+	// 2. Register them
+	migrationsMutex.Lock()
+	defer migrationsMutex.Unlock()
+	typeMigrations[t] = registered
+	return nil
+}
+
+// registeredMigrations returns the migrations registered for the struct type
+// t, or nil.
+func registeredMigrations(t reflect.Type) *migrations {
+	migrationsMutex.RLock()
+	defer migrationsMutex.RUnlock()
+	return typeMigrations[t]
+}
+
+// MarshalVersion returns the JSON encoding of v, like Marshal does, at the
+// given version of the struct type of v, by running the Down steps of the
+// migrations registered for it. Nested values are written at their current
+// version.
+func MarshalVersion(v interface{}, version int) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	registered := registeredMigrations(t)
+	if registered == nil {
+		return nil, fmt.Errorf("partialmarshal: no migrations are registered for %v", t)
+	}
+	if version < registered.first || version > registered.current {
+		return nil, fmt.Errorf("partialmarshal: cannot migrate %v to version %d", t, version)
+	}
+
+	encoded, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var rawMap map[string]json.RawMessage
+	err = json.Unmarshal(encoded, &rawMap)
+	if err != nil {
+		return nil, err
+	}
+
+	rawMap[registered.versionKey] = json.RawMessage(strconv.Itoa(registered.current))
+	for from := registered.current - 1; from >= version; from-- {
+		step := registered.steps[from]
+		if step.Down == nil {
+			return nil, fmt.Errorf("partialmarshal: migration of %v from version %d has no Down step", t, from)
+		}
+		err = step.Down(rawMap)
+		if err != nil {
+			return nil, fmt.Errorf("partialmarshal: migrating %v down to version %d: %v", t, from, err)
+		}
+		rawMap[registered.versionKey] = json.RawMessage(strconv.Itoa(from))
+	}
+	return json.Marshal(rawMap)
+}
+
+// upcast runs the Up steps registered for the struct type t on rawMap, from
+// the version it holds to the current version.
+func upcast(rawMap map[string]json.RawMessage, t reflect.Type) error {
+	registered := registeredMigrations(t)
+	if registered == nil {
+		return nil
+	}
+
+	// 1. Read the version of the object
+	version := 0
+	if rawVersion, found := rawMap[registered.versionKey]; found {
+		err := json.Unmarshal(rawVersion, &version)
+		if err != nil {
+			return fmt.Errorf("partialmarshal: invalid version %s of %v: %v", rawVersion, t, err)
+		}
+	}
+	if version == registered.current {
+		return nil
+	}
+	if version < registered.first || version > registered.current {
+		return fmt.Errorf("partialmarshal: cannot migrate %v from version %d", t, version)
+	}
+
+	// 2. Migrate it step by step
+	for from := version; from < registered.current; from++ {
+		err := registered.steps[from].Up(rawMap)
+		if err != nil {
+			return fmt.Errorf("partialmarshal: migrating %v up from version %d: %v", t, from, err)
+		}
+		rawMap[registered.versionKey] = json.RawMessage(strconv.Itoa(from + 1))
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// splitName moves the "name" key of version 1 objects into the "first" and
+// "last" keys of version 2 objects.
+var splitName = Migration{
+	From: 1,
+	Up: func(object map[string]json.RawMessage) error {
+		var name string
+		err := json.Unmarshal(object["name"], &name)
+		if err != nil {
+			return err
+		}
+		first, last, _ := strings.Cut(name, " ")
+		object["first"], _ = json.Marshal(first)
+		object["last"], _ = json.Marshal(last)
+		delete(object, "name")
+		return nil
+	},
+	Down: func(object map[string]json.RawMessage) error {
+		var first, last string
+		json.Unmarshal(object["first"], &first)
+		json.Unmarshal(object["last"], &last)
+		object["name"], _ = json.Marshal(first + " " + last)
+		delete(object, "first")
+		delete(object, "last")
+		return nil
+	},
+}
+
+func ExampleRegisterMigrations() {
+	// A struct type whose stored documents had a single name before version 2
+	type person struct {
+		Version int    `json:"version"`
+		First   string `json:"first"`
+		Last    string `json:"last"`
+		Extra
+	}
+	RegisterMigrations(person{}, "version", splitName)
+
+	var destination person
+	err := Unmarshal([]byte(`{"version": 1, "name": "Gopher Smith", "age": 25}`), &destination)
+	fmt.Println(err)
+	fmt.Println(destination.Version, destination.First, destination.Last)
+
+	JSONData, _ := MarshalVersion(destination, 1)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// 2 Gopher Smith
+	// {"age":25,"name":"Gopher Smith","version":1}
+}
+
+func TestRegisterMigrations(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+	}
+	up := func(object map[string]json.RawMessage) error { return nil }
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+		inMigrations    []Migration
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should register consecutive migrations in any order",
+			&testStruct{},
+			[]Migration{{From: 2, Up: up}, {From: 0, Up: up}, {From: 1, Up: up}},
+			"",
+		},
+		{
+			"should remove migrations when registering none",
+			testStruct{},
+			nil,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for a value that is not a struct",
+			"",
+			[]Migration{{From: 0, Up: up}},
+			"partialmarshal: cannot register migrations for string, which is not a struct",
+		},
+		{
+			"should return error for a migration without an Up step",
+			testStruct{},
+			[]Migration{{From: 0}},
+			"partialmarshal: migration of partialmarshal.testStruct from version 0 has no Up step",
+		},
+		{
+			"should return error for missing versions",
+			testStruct{},
+			[]Migration{{From: 0, Up: up}, {From: 2, Up: up}},
+			"partialmarshal: migrations of partialmarshal.testStruct skip or repeat version 1",
+		},
+		{
+			"should return error for repeated versions",
+			testStruct{},
+			[]Migration{{From: 0, Up: up}, {From: 0, Up: up}},
+			"partialmarshal: migrations of partialmarshal.testStruct skip or repeat version 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := RegisterMigrations(tc.inValue, "version", tc.inMigrations...)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUnmarshalMigrations(t *testing.T) {
+	type subStruct struct {
+		First string `json:"first"`
+		Last  string `json:"last"`
+		Extra
+	}
+	type testStruct struct {
+		Version int         `json:"v"`
+		People  []subStruct `json:"people"`
+		Extra
+	}
+	assert.NoError(t, RegisterMigrations(subStruct{}, "version", splitName))
+	assert.NoError(t, RegisterMigrations(testStruct{}, "v",
+		Migration{From: 0, Up: func(object map[string]json.RawMessage) error {
+			if persons, found := object["persons"]; found {
+				object["people"] = persons
+				delete(object, "persons")
+			}
+			return nil
+		}},
+		Migration{From: 1, Up: func(object map[string]json.RawMessage) error {
+			if _, found := object["fail"]; found {
+				return errors.New("cannot migrate")
+			}
+			return nil
+		}},
+	))
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should migrate objects without a version from version 0",
+			[]byte(`{"persons": [{"version": 1, "name": "Gopher Smith"}, {"version": 2, "first": "Go", "last": "Pher"}]}`),
+			`{"v":2,"people":[{"first":"Gopher","last":"Smith","version":2},{"first":"Go","last":"Pher","version":2}]}`,
+			"",
+		},
+		{
+			"should leave objects of the current version as they are",
+			[]byte(`{"v": 2, "persons": []}`),
+			`{"v":2,"people":null,"persons":[]}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for versions that cannot be migrated",
+			[]byte(`{"v": 3}`),
+			``,
+			"partialmarshal: cannot migrate partialmarshal.testStruct from version 3",
+		},
+		{
+			"should return error for nested versions that cannot be migrated",
+			[]byte(`{"people": [{"first": "Go"}]}`),
+			``,
+			"partialmarshal: cannot migrate partialmarshal.subStruct from version 0",
+		},
+		{
+			"should return error for versions that are not integers",
+			[]byte(`{"v": "2"}`),
+			``,
+			`partialmarshal: invalid version "2" of partialmarshal.testStruct: json: cannot unmarshal string into Go value of type int`,
+		},
+		{
+			"should return error when a step fails",
+			[]byte(`{"v": 1, "fail": true}`),
+			``,
+			"partialmarshal: migrating partialmarshal.testStruct up from version 1: cannot migrate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestMarshalVersion(t *testing.T) {
+	type testStruct struct {
+		First string `json:"first"`
+		Last  string `json:"last"`
+		Extra
+	}
+	type unregisteredStruct struct {
+		FieldOne string `json:"field_one"`
+	}
+	assert.NoError(t, RegisterMigrations(testStruct{}, "version",
+		Migration{From: 0, Up: func(object map[string]json.RawMessage) error { return nil }},
+		splitName,
+	))
+	value := testStruct{First: "Gopher", Last: "Smith", Extra: Extra{"age": json.RawMessage(`25`)}}
+
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+		inVersion       int
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should write the current version",
+			value,
+			2,
+			`{"age":25,"first":"Gopher","last":"Smith","version":2}`,
+			"",
+		},
+		{
+			"should migrate pointed to values down",
+			&value,
+			1,
+			`{"age":25,"name":"Gopher Smith","version":1}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for a missing Down step",
+			value,
+			0,
+			``,
+			"partialmarshal: migration of partialmarshal.testStruct from version 0 has no Down step",
+		},
+		{
+			"should return error for unknown versions",
+			value,
+			3,
+			``,
+			"partialmarshal: cannot migrate partialmarshal.testStruct to version 3",
+		},
+		{
+			"should return error for types without migrations",
+			unregisteredStruct{},
+			0,
+			``,
+			"partialmarshal: no migrations are registered for partialmarshal.unregisteredStruct",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			result, err := MarshalVersion(tc.inValue, tc.inVersion)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingStrategy returns the JSON key of a field from its Go name.
+type NamingStrategy func(name string) string
+
+var (
+	namingMutex      sync.RWMutex
+	namingStrategies = map[reflect.Type]NamingStrategy{}
+)
+
+// RegisterNamingStrategy sets the naming strategy of the struct type of v,
+// where v is a struct or a pointer to a struct. Registering a nil strategy
+// removes it.
+//
+// The fields of the type whose json tag has no name are then matched and
+// written under the key that the strategy derives from their Go name
+// instead of the Go name itself, so that keys matching no derived name are
+// kept in Extra. Fields with a name in their json tag are left as they are.
+func RegisterNamingStrategy(v interface{}, strategy NamingStrategy) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("partialmarshal: cannot register a naming strategy for %v, which is not a struct", t)
+	}
+
+	namingMutex.Lock()
+	defer namingMutex.Unlock()
+	defer forgetPartialTypes()
+	if strategy == nil {
+		delete(namingStrategies, t)
+	} else {
+		namingStrategies[t] = strategy
+	}
+	return nil
+}
+
+// namingFor returns the naming strategy registered for the struct type t, or
+// nil when its fields are named after their Go name.
+func namingFor(t reflect.Type) NamingStrategy {
+	namingMutex.RLock()
+	defer namingMutex.RUnlock()
+	return namingStrategies[t]
+}
+
+// SnakeCase is the naming strategy of keys such as "http_server_id".
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(nameWords(name), "_"))
+}
+
+// ScreamingSnakeCase is the naming strategy of keys such as "HTTP_SERVER_ID".
+func ScreamingSnakeCase(name string) string {
+	return strings.ToUpper(strings.Join(nameWords(name), "_"))
+}
+
+// KebabCase is the naming strategy of keys such as "http-server-id".
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(nameWords(name), "-"))
+}
+
+// CamelCase is the naming strategy of keys such as "httpServerId".
+func CamelCase(name string) string {
+	words := nameWords(name)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			first, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(first)) + word[size:]
+		}
+		words[i] = word
+	}
+	return strings.Join(words, "")
+}
+
+// nameWords splits the Go name into words, at lower to upper case changes
+// and before the last letter of a run of upper case letters followed by a
+// lower case letter, so that "HTTPServerID" is split into "HTTP", "Server"
+// and "ID". Digits stay with the word before them and underscores are
+// dropped.
+func nameWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		switch {
+		case i == len(runes) || runes[i] == '_':
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		case i > start && unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]):
+			words = append(words, string(runes[start:i]))
+			start = i
+		case i > start && unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return words
+}
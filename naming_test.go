@@ -0,0 +1,137 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleRegisterNamingStrategy() {
+	// A struct type of a snake_case API without json tags
+	type examplestruct struct {
+		UserName  string
+		HTTPPort  int
+		RequestID string `json:"request"`
+		Extra
+	}
+	RegisterNamingStrategy(examplestruct{}, SnakeCase)
+
+	var destination examplestruct
+	Unmarshal([]byte(`{"user_name": "gopher", "http_port": 8080, "request": "a", "UserName": "other"}`), &destination)
+	fmt.Println(destination.UserName, destination.HTTPPort, destination.RequestID)
+
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// gopher 8080 a
+	// {"UserName":"other","http_port":8080,"request":"a","user_name":"gopher"}
+}
+
+func TestNamingStrategies(t *testing.T) {
+	testCases := []struct {
+		inName                string
+		outSnakeCase          string
+		outScreamingSnakeCase string
+		outKebabCase          string
+		outCamelCase          string
+	}{
+		{"Name", "name", "NAME", "name", "name"},
+		{"UserName", "user_name", "USER_NAME", "user-name", "userName"},
+		{"HTTPServerID", "http_server_id", "HTTP_SERVER_ID", "http-server-id", "httpServerId"},
+		{"UserID2", "user_id2", "USER_ID2", "user-id2", "userId2"},
+		{"Field2Name", "field2_name", "FIELD2_NAME", "field2-name", "field2Name"},
+		{"Snake_Case", "snake_case", "SNAKE_CASE", "snake-case", "snakeCase"},
+		{"X", "x", "X", "x", "x"},
+		{"DataÉtat", "data_état", "DATA_ÉTAT", "data-état", "dataÉtat"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.inName, func(t *testing.T) {
+			assert.Equal(t, tc.outSnakeCase, SnakeCase(tc.inName))
+			assert.Equal(t, tc.outScreamingSnakeCase, ScreamingSnakeCase(tc.inName))
+			assert.Equal(t, tc.outKebabCase, KebabCase(tc.inName))
+			assert.Equal(t, tc.outCamelCase, CamelCase(tc.inName))
+		})
+	}
+}
+
+func TestRegisterNamingStrategy(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string
+		FieldTwo        Optional[int] `json:",omitempty"`
+		FieldTagged     string        `json:"tagged"`
+		FieldSubStruct  subStruct
+		FieldSubStructs []subStruct
+		Extra
+	}
+	assert.NoError(t, RegisterNamingStrategy(testStruct{}, KebabCase))
+	assert.NoError(t, RegisterNamingStrategy(&subStruct{}, func(name string) string { return "x_" + strings.ToLower(name) }))
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+	}{
+		{
+			"should match and write derived names at every level",
+			[]byte(`{
+				"field-one": "one",
+				"field-two": 2,
+				"tagged": "three",
+				"field-sub-struct": {"x_subfieldone": "sub one", "b": 1},
+				"field-sub-structs": [{"x_subfieldone": "sub two"}]
+			}`),
+			`{"field-one":"one","field-two":2,"tagged":"three","field-sub-struct":{"x_subfieldone":"sub one","b":1},"field-sub-structs":[{"x_subfieldone":"sub two"}]}`,
+		},
+		{
+			"should keep keys matching no derived name in Extra",
+			[]byte(`{"FieldOne": "one", "FieldTagged": "two", "field-sub-struct": {"SubFieldOne": "sub one"}}`),
+			`{"FieldOne":"one","field-one":"","tagged":"two","field-sub-struct":{"SubFieldOne":"sub one","x_subfieldone":""},"field-sub-structs":null}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			assert.NoError(t, err)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+
+	// Schemas and masks name fields the same way
+	schema, err := Schema(subStruct{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"properties":{"x_subfieldone":{"type":"string"}}`)
+	masked, err := MarshalMask(testStruct{FieldOne: "one"}, []string{"field-one"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field-one":"one"}`, string(masked))
+
+	// Registering no strategy removes it
+	assert.NoError(t, RegisterNamingStrategy(subStruct{}, nil))
+	result, err := Marshal(subStruct{SubFieldOne: "one"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"SubFieldOne":"one"}`, string(result))
+
+	// Strategies apply to structs held by structs without one
+	type plainStruct struct {
+		FieldSubStruct subStruct `json:"field_sub_struct"`
+	}
+	assert.NoError(t, RegisterNamingStrategy(subStruct{}, SnakeCase))
+	result, err = Marshal(plainStruct{FieldSubStruct: subStruct{SubFieldOne: "one"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_sub_struct":{"sub_field_one":"one"}}`, string(result))
+	assert.NoError(t, RegisterNamingStrategy(subStruct{}, nil))
+
+	err = RegisterNamingStrategy(1, SnakeCase)
+	assert.EqualError(t, err, "partialmarshal: cannot register a naming strategy for int, which is not a struct")
+}
@@ -0,0 +1,91 @@
+package partialmarshal
+
+import "reflect"
+
+// Optional - A generic field type that tells apart a missing key, an
+// explicit null and a value when unmarshaling.
+//
+// A key that is missing from the payload leaves the Optional unset. A key
+// with a null value sets Set and Null, and any other value sets Set and
+// Value. Marshal writes the field back the same way: the key is omitted
+// when the Optional is unset, null when it is null and the value otherwise.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+	Null  bool
+}
+
+// Nullable is an alias of Optional for fields where the explicit null is
+// the state of interest. Generic type aliases require Go 1.24 or later.
+type Nullable[T any] = Optional[T]
+
+// NewOptional returns an Optional holding value.
+func NewOptional[T any](value T) Optional[T] {
+	return Optional[T]{Value: value, Set: true}
+}
+
+// NewNull returns an Optional holding an explicit null.
+func NewNull[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+// Get returns the value of the Optional and whether it holds one, that is
+// whether it is neither unset nor null.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set && !o.Null
+}
+
+// IsZero reports whether the Optional is unset, so that the omitzero option
+// of encoding/json also omits it.
+func (o Optional[T]) IsZero() bool {
+	return !o.Set
+}
+
+// MarshalJSON writes null for an unset or null Optional and the partialmarshal
+// encoding of its value otherwise.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Set || o.Null {
+		return []byte("null"), nil
+	}
+	return Marshal(o.Value)
+}
+
+// UnmarshalJSON sets the Optional to null or to the decoded value. It is
+// only called for keys that are present in the payload.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		*o = NewNull[T]()
+		return nil
+	}
+	var result Optional[T]
+	value, err := decodeValue(data, reflect.TypeOf(&result.Value).Elem())
+	if err != nil && asRequiredError(err) == nil {
+		return err
+	}
+	reflect.ValueOf(&result.Value).Elem().Set(value)
+	result.Set = true
+	*o = result
+	return err
+}
+
+func (o Optional[T]) isUnset() bool {
+	return !o.Set
+}
+
+// optionalValue is implemented by Optional, whose key is omitted by Marshal
+// when it is unset.
+type optionalValue interface {
+	isUnset() bool
+}
+
+var optionalValueType = reflect.TypeOf((*optionalValue)(nil)).Elem()
+
+// hasOptionalFields reports whether the struct type has Optional fields.
+func hasOptionalFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).Type.Implements(optionalValueType) {
+			return true
+		}
+	}
+	return false
+}
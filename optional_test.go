@@ -0,0 +1,175 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleOptional() {
+	// A struct type with Optional fields and partialmarshal.Extra
+	type examplestruct struct {
+		Name     string           `json:"name"`
+		Nickname Optional[string] `json:"nickname"`
+		Age      Nullable[int]    `json:"age"`
+		Extra
+	}
+
+	var destination examplestruct
+	err := Unmarshal([]byte(`{"name": "gopher", "age": null, "height": 180}`), &destination)
+	fmt.Println(err)
+	fmt.Println(destination.Nickname.Set)
+	fmt.Println(destination.Age.Set, destination.Age.Null)
+
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// false
+	// true true
+	// {"age":null,"height":180,"name":"gopher"}
+}
+
+func TestOptional(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne   Optional[string]            `json:"field_one"`
+		FieldTwo   Nullable[int]               `json:"field_two"`
+		FieldThree Optional[subStruct]         `json:"field_three"`
+		FieldFour  Optional[map[string]string] `json:"field_four"`
+		FieldFive  Optional[[]subStruct]       `json:"field_five"`
+	}
+	type testStructWithExtra struct {
+		FieldOne Optional[string] `json:"field_one"`
+		FieldTwo Nullable[int]    `json:"field_two"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		inStruct        interface{}
+		outStruct       interface{}
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should leave missing keys unset",
+			[]byte(`{}`),
+			&testStruct{},
+			&testStruct{},
+			`{}`,
+			"",
+		},
+		{
+			"should set explicit nulls",
+			[]byte(`{"field_one": null, "field_two": null, "field_three": null}`),
+			&testStruct{},
+			&testStruct{
+				FieldOne:   NewNull[string](),
+				FieldTwo:   NewNull[int](),
+				FieldThree: NewNull[subStruct](),
+			},
+			`{"field_one":null,"field_two":null,"field_three":null}`,
+			"",
+		},
+		{
+			"should set values including zero values",
+			[]byte(`{"field_one": "", "field_two": 0, "field_four": {"a": "b"}}`),
+			&testStruct{},
+			&testStruct{
+				FieldOne:  NewOptional(""),
+				FieldTwo:  NewOptional(0),
+				FieldFour: NewOptional(map[string]string{"a": "b"}),
+			},
+			`{"field_one":"","field_two":0,"field_four":{"a":"b"}}`,
+			"",
+		},
+		{
+			"should keep extra payload of optional substructs",
+			[]byte(`{"field_three": {"sub_field_one": "one", "sub_field_two": "two"}, "field_five": [{"sub_field_two": "two"}]}`),
+			&testStruct{},
+			&testStruct{
+				FieldThree: NewOptional(subStruct{"one", Extra{"sub_field_two": []byte(`"two"`)}}),
+				FieldFive:  NewOptional([]subStruct{{"", Extra{"sub_field_two": []byte(`"two"`)}}}),
+			},
+			`{"field_three":{"sub_field_one":"one","sub_field_two":"two"},"field_five":[{"sub_field_one":"","sub_field_two":"two"}]}`,
+			"",
+		},
+		{
+			"should work together with extra in the same struct",
+			[]byte(`{"field_two": null, "field_three": 3}`),
+			&testStructWithExtra{},
+			&testStructWithExtra{
+				FieldTwo: NewNull[int](),
+				Extra:    Extra{"field_three": []byte(`3`)},
+			},
+			`{"field_two":null,"field_three":3}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when value has wrong type",
+			[]byte(`{"field_two": "two"}`),
+			&testStruct{},
+			&testStruct{},
+			``,
+			"json: cannot unmarshal string into Go value of type int",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := Unmarshal(tc.inData, tc.inStruct)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outStruct, tc.inStruct)
+			result, err := Marshal(tc.inStruct)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestOptionalGet(t *testing.T) {
+	value, ok := Optional[int]{}.Get()
+	assert.Equal(t, 0, value)
+	assert.False(t, ok)
+
+	value, ok = NewNull[int]().Get()
+	assert.Equal(t, 0, value)
+	assert.False(t, ok)
+
+	value, ok = NewOptional(3).Get()
+	assert.Equal(t, 3, value)
+	assert.True(t, ok)
+}
+
+func TestOptionalMergePatch(t *testing.T) {
+	type testStruct struct {
+		FieldOne Optional[string] `json:"field_one"`
+		FieldTwo Optional[string] `json:"field_two"`
+	}
+	value := testStruct{NewOptional("one"), NewOptional("two")}
+	assert.NoError(t, MergePatch(&value, []byte(`{"field_one": null, "field_two": "new two"}`)))
+	assert.Equal(t, testStruct{FieldTwo: NewOptional("new two")}, value)
+}
+
+func TestOptionalOmitZero(t *testing.T) {
+	// Plain encoding/json omits unset Optionals tagged with omitzero
+	result, err := json.Marshal(struct {
+		FieldOne Optional[string] `json:"field_one,omitzero"`
+		FieldTwo Optional[string] `json:"field_two,omitzero"`
+	}{FieldTwo: NewNull[string]()})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"field_two":null}`, string(result))
+}
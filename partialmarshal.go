@@ -9,6 +9,61 @@ import (
 // a storage location for extra payloads when unmarshaling.
 type Extra map[string]json.RawMessage
 
+// isExtraField reports whether field is the embedded partialmarshal.Extra,
+// or a field of type Extra tagged `partialmarshal:",extra"`.
+func isExtraField(field reflect.StructField) bool {
+	return field.Type == reflect.TypeOf(Extra{}) && (field.Anonymous || isTaggedExtra(field))
+}
+
+// isTaggedExtra reports whether field is tagged `partialmarshal:",extra"`.
+func isTaggedExtra(field reflect.StructField) bool {
+	tag, err := parseFieldTag(field)
+	return err == nil && tag.extra
+}
+
+// isStorageField reports whether field stores something other than the value
+// of a key: the extra payload, the presence of fields or the extensions.
+func isStorageField(field reflect.StructField) bool {
+	return isExtraField(field) || isExtraOfField(field) || isPresenceField(field) || isExtensionsField(field)
+}
+
+// extraFieldOf returns the Extra storage of the struct reflectedValue, which
+// may be promoted from an embedded struct, or an invalid value when there is
+// none.
+func extraFieldOf(reflectedValue reflect.Value) reflect.Value {
+	if reflectedValue.Type().Implements(documentType) {
+		return reflect.Value{}
+	}
+	for i := 0; i < reflectedValue.NumField(); i++ {
+		if isExtraField(reflectedValue.Type().Field(i)) {
+			return reflectedValue.Field(i)
+		}
+	}
+	extraField := reflectedValue.FieldByName("Extra")
+	if extraField.IsValid() && extraField.Type() != reflect.TypeOf(Extra{}) {
+		return reflect.Value{}
+	}
+	return extraField
+}
+
+// hasExtraStorage reports whether the struct type t embeds Extra or ExtraOf,
+// or has a field tagged to store its extra payload.
+func hasExtraStorage(t reflect.Type) bool {
+	if t.Implements(documentType) {
+		// The Extra of a Document is written into the object of its value
+		return false
+	}
+	if _, found := t.FieldByName("Extra"); found {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if isExtraField(t.Field(i)) || isExtraOfField(t.Field(i)) {
+			return true
+		}
+	}
+	return false
+}
+
 func getReflectedValue(v interface{}) (reflect.Value, error) {
 	reflectedValue := reflect.ValueOf(v)
 	if reflectedValue.Kind() != reflect.Ptr || reflectedValue.IsNil() {
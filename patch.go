@@ -0,0 +1,400 @@
+package partialmarshal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchError describes a JSON Patch operation that could not be applied.
+type PatchError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("partialmarshal: patch operation %d (%s %q): %s", e.Index, e.Op, e.Path, e.Err)
+}
+
+// ApplyPatch applies the RFC 6902 JSON Patch document ops to the value
+// pointed to by v.
+//
+// Paths are resolved against the JSON encoding produced by Marshal, so they
+// may point into typed fields, slices of substructs and the raw content of
+// partialmarshal.Extra alike. The patch is applied atomically: if any
+// operation fails, v is left unchanged and a *PatchError naming the index of
+// the failing operation is returned. Fields left out of the encoding, such as
+// unexported fields and fields tagged `json:"-"`, keep their value.
+func ApplyPatch(v interface{}, ops []byte) error {
+	reflectedValue, err := getReflectedValue(v)
+	if err != nil {
+		return err
+	}
+
+	var operations []patchOperation
+	err = json.Unmarshal(ops, &operations)
+	if err != nil {
+		return err
+	}
+
+	// 1. Convert the value v into a generic JSON document
+	document, err := marshalDocument(v)
+	if err != nil {
+		return err
+	}
+
+	// 2. Apply every operation to the document, checking that the result
+	// still decodes into the type of v.
+	result := reflect.New(reflectedValue.Type())
+	for i, op := range operations {
+		document, err = op.apply(document)
+		if err == nil {
+			result = reflect.New(reflectedValue.Type())
+			err = unmarshalDocument(document, result.Interface())
+		}
+		if err != nil {
+			return &PatchError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	// 3. Only update v once every operation has succeeded
+	if len(operations) > 0 {
+		copyEncodedFields(reflectedValue, result.Elem())
+	}
+	return nil
+}
+
+// copyEncodedFields sets the fields of the struct dst that the JSON encoding
+// produced by Marshal covers to their value in src. Nested structs are copied
+// field by field, so that their fields left out of the encoding keep their
+// value too.
+func copyEncodedFields(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.PkgPath != "" || isSkipped(field) {
+			continue
+		}
+		switch {
+		case isEncodedStruct(field.Type):
+			copyEncodedFields(dst.Field(i), src.Field(i))
+		case isStructPointer(field.Type) && isEncodedStruct(field.Type.Elem()) && !dst.Field(i).IsNil() && !src.Field(i).IsNil():
+			// The struct pointed to may be shared, so a copy of it is updated
+			copied := reflect.New(field.Type.Elem())
+			copied.Elem().Set(dst.Field(i).Elem())
+			copyEncodedFields(copied.Elem(), src.Field(i).Elem())
+			dst.Field(i).Set(copied)
+		default:
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// isEncodedStruct reports whether t is a struct type that Marshal encodes
+// field by field, rather than one that decodes itself, such as Optional.
+func isEncodedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (op patchOperation) apply(document interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		value, err := decodeDocument(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "add" {
+			return addPointer(document, path, value)
+		}
+		current, err := getPointer(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "test" {
+			if !documentsEqual(current, value) {
+				return nil, errors.New("test failed")
+			}
+			return document, nil
+		}
+		document, err = removePointer(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, path, value)
+	case "remove":
+		return removePointer(document, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getPointer(document, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return addPointer(document, path, copyDocument(value))
+		}
+		if isPointerPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		document, err = removePointer(document, from)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, path, value)
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// formatPointer joins tokens into an RFC 6901 JSON Pointer.
+func formatPointer(tokens []string) string {
+	var buffer bytes.Buffer
+	for _, token := range tokens {
+		buffer.WriteString("/")
+		buffer.WriteString(strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1))
+	}
+	return buffer.String()
+}
+
+func isPointerPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getPointer(document interface{}, tokens []string) (interface{}, error) {
+	for i, token := range tokens {
+		switch container := document.(type) {
+		case map[string]interface{}:
+			value, found := container[token]
+			if !found {
+				return nil, fmt.Errorf("path %s does not exist", formatPointer(tokens[:i+1]))
+			}
+			document = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			document = container[index]
+		default:
+			return nil, fmt.Errorf("path %s does not exist", formatPointer(tokens[:i+1]))
+		}
+	}
+	return document, nil
+}
+
+// updatePointer calls update with the parent container of the location
+// referenced by tokens and stores the container it returns in its place.
+func updatePointer(document interface{}, tokens []string, update func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(document, tokens[0])
+	}
+	child, err := getPointer(document, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updatePointer(child, tokens[1:], update)
+	if err != nil {
+		return nil, err
+	}
+	switch container := document.(type) {
+	case map[string]interface{}:
+		container[tokens[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(tokens[0], len(container)-1)
+		container[index] = child
+	}
+	return document, nil
+}
+
+func addPointer(document interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updatePointer(document, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			if token == "-" {
+				return append(container, value), nil
+			}
+			index, err := arrayIndex(token, len(container))
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("path %s does not exist", formatPointer(tokens))
+	})
+}
+
+func removePointer(document interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return updatePointer(document, tokens, func(container interface{}, token string) (interface{}, error) {
+		switch container := container.(type) {
+		case map[string]interface{}:
+			if _, found := container[token]; !found {
+				return nil, fmt.Errorf("path %s does not exist", formatPointer(tokens))
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("path %s does not exist", formatPointer(tokens))
+	})
+}
+
+// arrayIndex parses an array index token, which must not exceed max.
+func arrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d out of bounds", index)
+	}
+	return index, nil
+}
+
+// decodeDocument decodes data into a generic JSON document, keeping numbers
+// as json.Number so that they are not altered when encoded again.
+func decodeDocument(data []byte) (interface{}, error) {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&document)
+	return document, err
+}
+
+// marshalDocument returns the JSON encoding of v produced by Marshal as a
+// generic JSON document.
+func marshalDocument(v interface{}) (interface{}, error) {
+	encoded, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDocument(encoded)
+}
+
+func unmarshalDocument(document interface{}, v interface{}) error {
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(encoded, v)
+}
+
+func copyDocument(document interface{}) interface{} {
+	switch document := document.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(document))
+		for key, value := range document {
+			result[key] = copyDocument(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(document))
+		for i, value := range document {
+			result[i] = copyDocument(value)
+		}
+		return result
+	}
+	return document
+}
+
+// documentsEqual compares two generic JSON documents, treating numbers as
+// equal when their values are equal.
+func documentsEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, found := b[key]
+			if !found || !documentsEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !documentsEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == b {
+			return true
+		}
+		aFloat, aErr := a.Float64()
+		bFloat, bErr := b.Float64()
+		return aErr == nil && bErr == nil && aFloat == bFloat
+	}
+	return a == b
+}
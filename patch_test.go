@@ -0,0 +1,312 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleApplyPatch() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo string `json:"example_field_two"`
+		Extra
+	}
+
+	destination := examplestruct{
+		"Value 1",
+		"Value 2",
+		Extra{
+			"some_other_field": []byte(`"some other value"`),
+		},
+	}
+
+	// Move an extra value into a known field.
+	err := ApplyPatch(&destination, []byte(`[
+		{"op": "test", "path": "/some_other_field", "value": "some other value"},
+		{"op": "move", "from": "/some_other_field", "path": "/example_field_two"}
+	]`))
+	fmt.Println(err)
+	fmt.Println(destination.ExampleFieldTwo)
+	fmt.Println(len(destination.Extra))
+
+	// Output:
+	// <nil>
+	// some other value
+	// 0
+}
+
+func TestApplyPatch(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string      `json:"field_one"`
+		FieldTwo        int         `json:"field_two"`
+		FieldSubStructs []subStruct `json:"field_sub_structs"`
+		Extra
+	}
+	newTestStruct := func() *testStruct {
+		return &testStruct{
+			"value one",
+			2,
+			[]subStruct{
+				{"sub value one", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+			},
+			Extra{"field_three": []byte(`{"a":[1,2]}`)},
+		}
+	}
+	testCases := []struct {
+		testDescription string
+		inStruct        interface{}
+		inOps           []byte
+		outStruct       interface{}
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should replace typed field",
+			newTestStruct(),
+			[]byte(`[{"op": "replace", "path": "/field_two", "value": 5}]`),
+			&testStruct{
+				"value one",
+				5,
+				[]subStruct{
+					{"sub value one", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+				},
+				Extra{"field_three": []byte(`{"a":[1,2]}`)},
+			},
+			"",
+		},
+		{
+			"should remove typed field",
+			newTestStruct(),
+			[]byte(`[{"op": "remove", "path": "/field_one"}]`),
+			&testStruct{
+				"",
+				2,
+				[]subStruct{
+					{"sub value one", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+				},
+				Extra{"field_three": []byte(`{"a":[1,2]}`)},
+			},
+			"",
+		},
+		{
+			"should add into extra content",
+			newTestStruct(),
+			[]byte(`[{"op": "add", "path": "/field_three/a/1", "value": 3}, {"op": "add", "path": "/field_four", "value": null}]`),
+			&testStruct{
+				"value one",
+				2,
+				[]subStruct{
+					{"sub value one", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+				},
+				Extra{
+					"field_three": []byte(`{"a":[1,3,2]}`),
+					"field_four":  []byte(`null`),
+				},
+			},
+			"",
+		},
+		{
+			"should patch slices of substructs and their extra",
+			newTestStruct(),
+			[]byte(`[
+				{"op": "copy", "from": "/field_sub_structs/0", "path": "/field_sub_structs/-"},
+				{"op": "replace", "path": "/field_sub_structs/1/sub_field_one", "value": "copied"},
+				{"op": "remove", "path": "/field_sub_structs/0/sub_field_two"}
+			]`),
+			&testStruct{
+				"value one",
+				2,
+				[]subStruct{
+					{"sub value one", Extra{}},
+					{"copied", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+				},
+				Extra{"field_three": []byte(`{"a":[1,2]}`)},
+			},
+			"",
+		},
+		{
+			"should move extra content into typed field",
+			newTestStruct(),
+			[]byte(`[{"op": "move", "from": "/field_three/a/1", "path": "/field_two"}]`),
+			&testStruct{
+				"value one",
+				2,
+				[]subStruct{
+					{"sub value one", Extra{"sub_field_two": []byte(`"sub value two"`)}},
+				},
+				Extra{"field_three": []byte(`{"a":[1]}`)},
+			},
+			"",
+		},
+		{
+			"should pass test operation with equal numbers",
+			newTestStruct(),
+			[]byte(`[{"op": "test", "path": "/field_two", "value": 2.0}, {"op": "test", "path": "/field_three", "value": {"a": [1, 2]}}]`),
+			newTestStruct(),
+			"",
+		},
+		{
+			"should unescape JSON pointer tokens",
+			&testStruct{},
+			[]byte(`[{"op": "add", "path": "/a~1b~0c", "value": 1}]`),
+			&testStruct{Extra: Extra{"a/b~c": []byte(`1`)}},
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when provided value not struct pointer",
+			testStruct{},
+			[]byte(`[]`),
+			testStruct{},
+			"json: Unmarshal(non-pointer partialmarshal.testStruct)",
+		},
+		{
+			"should leave value unchanged and name index of failed test operation",
+			newTestStruct(),
+			[]byte(`[{"op": "replace", "path": "/field_one", "value": "changed"}, {"op": "test", "path": "/field_two", "value": 3}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 1 (test "/field_two"): test failed`,
+		},
+		{
+			"should leave value unchanged when path does not exist",
+			newTestStruct(),
+			[]byte(`[{"op": "remove", "path": "/field_three/b"}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (remove "/field_three/b"): path /field_three/b does not exist`,
+		},
+		{
+			"should return error when value does not fit typed field",
+			newTestStruct(),
+			[]byte(`[{"op": "replace", "path": "/field_two", "value": "two"}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (replace "/field_two"): json: cannot unmarshal string into Go value of type int`,
+		},
+		{
+			"should return error when array index out of bounds",
+			newTestStruct(),
+			[]byte(`[{"op": "add", "path": "/field_sub_structs/2", "value": {}}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (add "/field_sub_structs/2"): array index 2 out of bounds`,
+		},
+		{
+			"should return error when moving value into its own child",
+			newTestStruct(),
+			[]byte(`[{"op": "move", "from": "/field_three", "path": "/field_three/a/0"}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (move "/field_three/a/0"): cannot move a value into one of its children`,
+		},
+		{
+			"should return error on unknown operation",
+			newTestStruct(),
+			[]byte(`[{"op": "frobnicate", "path": "/field_one"}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (frobnicate "/field_one"): unknown operation "frobnicate"`,
+		},
+		{
+			"should return error on missing value",
+			newTestStruct(),
+			[]byte(`[{"op": "add", "path": "/field_one"}]`),
+			newTestStruct(),
+			`partialmarshal: patch operation 0 (add "/field_one"): missing value`,
+		},
+		{
+			"should return error when provided with malformed JSON",
+			newTestStruct(),
+			[]byte(`decidedly not json in format`),
+			newTestStruct(),
+			"invalid character 'd' looking for beginning of value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := ApplyPatch(tc.inStruct, tc.inOps)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.outStruct, tc.inStruct)
+		})
+	}
+}
+
+func TestApplyPatchKeepsUnencodedFields(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Cache       string `json:"-"`
+	}
+	type testStruct struct {
+		FieldOne string     `json:"field_one"`
+		Secret   string     `json:"-"`
+		Skipped  string     `partialmarshal:"-"`
+		Sub      subStruct  `json:"sub"`
+		SubPtr   *subStruct `json:"sub_ptr"`
+		counter  int
+		Extra
+	}
+
+	shared := &subStruct{"pointed value", "pointed cache"}
+	value := testStruct{
+		FieldOne: "value one",
+		Secret:   "secret",
+		Skipped:  "skipped",
+		Sub:      subStruct{"sub value", "sub cache"},
+		SubPtr:   shared,
+		counter:  3,
+	}
+	err := ApplyPatch(&value, []byte(`[
+		{"op": "replace", "path": "/field_one", "value": "changed"},
+		{"op": "replace", "path": "/sub/sub_field_one", "value": "sub changed"},
+		{"op": "replace", "path": "/sub_ptr/sub_field_one", "value": "pointed changed"}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, testStruct{
+		FieldOne: "changed",
+		Secret:   "secret",
+		Skipped:  "skipped",
+		Sub:      subStruct{"sub changed", "sub cache"},
+		SubPtr:   &subStruct{"pointed changed", "pointed cache"},
+		counter:  3,
+		Extra:    Extra{},
+	}, value)
+
+	// The struct pointed to before the patch is left as it was
+	assert.Equal(t, &subStruct{"pointed value", "pointed cache"}, shared)
+}
+
+func TestParsePointer(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inPointer       string
+		outTokens       []string
+		outErrMsg       string
+	}{
+		// Happy Path
+		{"should parse root pointer", "", []string{}, ""},
+		{"should parse nested pointer", "/a/0/b", []string{"a", "0", "b"}, ""},
+		{"should parse empty token", "/", []string{""}, ""},
+		{"should unescape tokens", "/m~0n/a~1b", []string{"m~n", "a/b"}, ""},
+		// Sad Path
+		{"should return error when missing leading slash", "a/b", nil, `invalid JSON pointer "a/b"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			tokens, err := parsePointer(tc.inPointer)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.outTokens, tokens)
+				assert.Equal(t, tc.inPointer, formatPointer(tokens))
+			}
+		})
+	}
+}
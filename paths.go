@@ -0,0 +1,152 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// keyPath returns the tokens of the path that key refers to for a field with
+// the partialmarshal tag options tag. Keys of the partialmarshal tag with
+// dots, such as "meta.author.name", refer to a key nested in intermediate
+// objects, and all other keys to a key of the object itself.
+func keyPath(key string, tag fieldTag) []string {
+	if !strings.Contains(key, ".") {
+		return []string{key}
+	}
+	if key == tag.name {
+		return strings.Split(key, ".")
+	}
+	for _, alias := range tag.aliases {
+		if key == alias {
+			return strings.Split(key, ".")
+		}
+	}
+	return []string{key}
+}
+
+// fieldPath returns the tokens of the path that field is written under by
+// Marshal, given the naming strategy of its struct type.
+func fieldPath(field reflect.StructField, naming NamingStrategy) []string {
+	tag, _ := parseFieldTag(field)
+	return keyPath(jsonName(field, naming), tag)
+}
+
+// lookupPath returns the value at the path tokens below rawMap.
+func lookupPath(rawMap map[string]json.RawMessage, tokens []string) (json.RawMessage, bool) {
+	rawValue, found := rawMap[tokens[0]]
+	if !found || len(tokens) == 1 {
+		return rawValue, found
+	}
+	var nested map[string]json.RawMessage
+	if !isJSONObject(rawValue) || json.Unmarshal(rawValue, &nested) != nil {
+		return nil, false
+	}
+	return lookupPath(nested, tokens[1:])
+}
+
+// popPath removes the value at the path tokens below rawMap and returns it.
+// The intermediate objects keep their other keys, so that they end up in
+// Extra, and are removed once they have no keys left.
+func popPath(rawMap map[string]json.RawMessage, tokens []string) (json.RawMessage, bool) {
+	rawValue, found := rawMap[tokens[0]]
+	if !found {
+		return nil, false
+	}
+	if len(tokens) == 1 {
+		delete(rawMap, tokens[0])
+		return rawValue, true
+	}
+
+	var nested map[string]json.RawMessage
+	if !isJSONObject(rawValue) || json.Unmarshal(rawValue, &nested) != nil {
+		return nil, false
+	}
+	value, found := popPath(nested, tokens[1:])
+	if !found {
+		return nil, false
+	}
+	if len(nested) == 0 {
+		delete(rawMap, tokens[0])
+	} else {
+		rawMap[tokens[0]], _ = json.Marshal(nested)
+	}
+	return value, true
+}
+
+// nestedValue is the value of a field written under a nested key.
+type nestedValue struct {
+	path  []string
+	value interface{}
+}
+
+// setPath sets the value at the path tokens below object. Missing
+// intermediate objects are created, and the ones already set, such as the
+// remaining keys of an intermediate object kept in Extra, are merged into.
+func setPath(object map[string]interface{}, tokens []string, value interface{}) error {
+	if len(tokens) == 1 {
+		object[tokens[0]] = value
+		return nil
+	}
+	nested, ok := object[tokens[0]].(map[string]interface{})
+	if !ok {
+		nested = map[string]interface{}{}
+		if rawValue, isRaw := object[tokens[0]].(json.RawMessage); isRaw && isJSONObject(rawValue) {
+			err := json.Unmarshal(rawValue, &nested)
+			if err != nil {
+				return err
+			}
+		}
+		object[tokens[0]] = nested
+	}
+	return setPath(nested, tokens[1:], value)
+}
+
+// pathNode is a tree of the keys of the intermediate objects on the way to
+// nested keys.
+type pathNode map[string]pathNode
+
+// intermediatePaths adds the intermediate objects of the nested keys of the
+// fields of the struct type t, including the fields of inline structs, to
+// tree and returns it.
+func intermediatePaths(t reflect.Type, tree pathNode) pathNode {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isStorageField(field) || field.PkgPath != "" {
+			continue
+		}
+		tag, _ := parseFieldTag(field)
+		switch {
+		case tag.skip:
+		case tag.inline:
+			intermediatePaths(inlineType(field.Type), tree)
+		default:
+			for _, key := range append([]string{tag.name}, tag.aliases...) {
+				tokens := keyPath(key, tag)
+				node := tree
+				for _, token := range tokens[:len(tokens)-1] {
+					if node[token] == nil {
+						node[token] = pathNode{}
+					}
+					node = node[token]
+				}
+			}
+		}
+	}
+	return tree
+}
+
+// visitRemaining calls visit for each key left in rawMap by popFields. The
+// intermediate objects in tree are descended into, so that each of their
+// remaining keys is visited at its own path.
+func visitRemaining(rawMap map[string]json.RawMessage, tree pathNode, path []string, visit func(path []string, rawValue json.RawMessage)) {
+	for key, rawValue := range rawMap {
+		remainingPath := append(path[:len(path):len(path)], key)
+		var nested map[string]json.RawMessage
+		if node, found := tree[key]; found && isJSONObject(rawValue) && json.Unmarshal(rawValue, &nested) == nil {
+			visitRemaining(nested, node, remainingPath, visit)
+			continue
+		}
+		visit(remainingPath, rawValue)
+	}
+}
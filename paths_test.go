@@ -0,0 +1,205 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Example_nestedPaths() {
+	// A struct type that flattens the author of an upstream payload
+	type examplestruct struct {
+		Title       string `json:"title"`
+		AuthorName  string `partialmarshal:"meta.author.name"`
+		AuthorEmail string `partialmarshal:"meta.author.email"`
+		Extra
+	}
+
+	var destination examplestruct
+	Unmarshal([]byte(`{"title": "Gophers", "meta": {"author": {"name": "Gopher", "email": "gopher@example.com", "id": 7}, "tags": ["go"]}}`), &destination)
+	fmt.Println(destination.AuthorName, destination.AuthorEmail)
+	fmt.Println(string(destination.Extra["meta"]))
+
+	destination.AuthorName = "Gopher Smith"
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// Gopher gopher@example.com
+	// {"author":{"id":7},"tags":["go"]}
+	// {"meta":{"author":{"email":"gopher@example.com","id":7,"name":"Gopher Smith"},"tags":["go"]},"title":"Gophers"}
+}
+
+func TestUnmarshalNestedPaths(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `partialmarshal:"a.b,required"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne   string      `json:"field_one"`
+		AuthorName string      `partialmarshal:"meta.author.name,aliases=author_name"`
+		AuthorID   int         `partialmarshal:"meta.author.id"`
+		Sub        subStruct   `partialmarshal:"meta.sub"`
+		Subs       []subStruct `json:"subs"`
+		Extra
+	}
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should match nested keys and keep their siblings",
+			[]byte(`{"field_one": "one", "meta": {"author": {"name": "Gopher", "id": 7, "email": "e"}, "tags": []}, "subs": [{"a": {"b": "two", "c": 3}}]}`),
+			`{"field_one":"one","meta":{"author":{"name":"Gopher","id":7,"email":"e"},"sub":{"a":{"b":""}},"tags":[]},"subs":[{"a":{"b":"two","c":3}}]}`,
+			"",
+		},
+		{
+			"should match nested aliases",
+			[]byte(`{"author_name": "Gopher", "meta": {"sub": {"a": {"b": "one"}}}, "subs": []}`),
+			`{"field_one":"","meta":{"author":{"name":"Gopher","id":0},"sub":{"a":{"b":"one"}}},"subs":[]}`,
+			"",
+		},
+		{
+			"should not match nested keys below values that are not objects",
+			[]byte(`{"meta": 5, "subs": null}`),
+			`{"field_one":"","meta":{"author":{"name":"","id":0},"sub":{"a":{"b":""}}},"subs":null}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for missing nested required keys",
+			[]byte(`{"meta": {"sub": {"a": {}}}, "subs": [{"a": {"c": 1}}]}`),
+			``,
+			`partialmarshal: missing required keys: "/meta/sub/a/b", "/subs/0/a/b"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestNestedPathsUnknowns(t *testing.T) {
+	type testStruct struct {
+		FieldOne   string `json:"field_one"`
+		AuthorName string `partialmarshal:"meta.author.name"`
+	}
+
+	var value testStruct
+	unknowns, err := UnmarshalWithUnknowns([]byte(`{"field_one": "one", "meta": {"author": {"name": "Gopher", "id": 7}, "tags": ["go"]}}`), &value)
+	assert.NoError(t, err)
+	assert.Equal(t, Unknowns{"/meta/author/id": []byte(`7`), "/meta/tags": []byte(`["go"]`)}, unknowns)
+
+	value.AuthorName = "Gopher Smith"
+	result, err := MarshalWithUnknowns(value, unknowns)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"one","meta":{"author":{"name":"Gopher Smith","id":7},"tags":["go"]}}`, string(result))
+}
+
+func TestNestedPathsMergePatch(t *testing.T) {
+	type testStruct struct {
+		AuthorName string `partialmarshal:"meta.author.name"`
+		Extra
+	}
+
+	value := testStruct{AuthorName: "Gopher", Extra: Extra{"meta": []byte(`{"author":{"id":7}}`)}}
+	err := MergePatch(&value, []byte(`{"meta": {"author": {"name": "Gopher Smith", "id": null}, "tags": ["go"]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Gopher Smith", value.AuthorName)
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"meta":{"author":{"name":"Gopher Smith"},"tags":["go"]}}`, string(result))
+}
+
+func TestNestedPathsSchemaAndMask(t *testing.T) {
+	type testStruct struct {
+		FieldOne   string `json:"field_one"`
+		AuthorName string `partialmarshal:"meta.author.name"`
+		AuthorID   *int   `partialmarshal:"meta.author.id"`
+	}
+
+	schema, err := Schema(testStruct{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/testStruct",
+		"$defs": {"testStruct": {
+			"type": "object",
+			"properties": {
+				"field_one": {"type": "string"},
+				"meta": {
+					"type": "object",
+					"properties": {"author": {
+						"type": "object",
+						"properties": {"name": {"type": "string"}, "id": {"anyOf": [{"type": "integer"}, {"type": "null"}]}},
+						"additionalProperties": false,
+						"required": ["name"]
+					}},
+					"additionalProperties": false,
+					"required": ["author"]
+				}
+			},
+			"additionalProperties": false,
+			"required": ["field_one", "meta"]
+		}}
+	}`, string(schema))
+
+	masked, err := MarshalMask(testStruct{FieldOne: "one", AuthorName: "Gopher"}, []string{"meta.author.name"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"meta":{"author":{"name":"Gopher"}}}`, string(masked))
+}
+
+func TestParseFieldTagPaths(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inTag           reflect.StructTag
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should accept nested keys and aliases",
+			`partialmarshal:"meta.author.name,aliases=meta.name|author_name"`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for empty path segments",
+			`partialmarshal:"meta..name"`,
+			`key "meta..name" has an empty path segment`,
+		},
+		{
+			"should return error for empty path segments of aliases",
+			`partialmarshal:"meta.name,aliases=.name"`,
+			`key ".name" has an empty path segment`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			field := reflect.StructField{Name: "FieldOne", Type: reflect.TypeOf(""), Tag: tc.inTag}
+			_, err := parseFieldTag(field)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package partialmarshal
+
+import "reflect"
+
+// Presence - A type provided for use as an embedded type to record which
+// fields of a struct were present in the JSON payload when unmarshaling.
+//
+// Fields are recorded by the JSON name that Marshal writes them under. When
+// a struct embeds a non-nil Presence, Marshal only emits the fields that are
+// recorded as present, so that a decoded value is written back without the
+// fields that were absent from its payload.
+//
+// Presence only records the fields of its own struct: a field with a nested
+// key is recorded by the dotted path of its partialmarshal tag, such as
+// "meta.author.name", and the fields of an inline struct are recorded by
+// the Presence that the inline struct embeds, if any.
+type Presence map[string]bool
+
+// IsSet reports whether the field with the given JSON name was present.
+func (p Presence) IsSet(name string) bool {
+	return p[name]
+}
+
+// Set records the field with the given JSON name as present, so that it is
+// emitted by Marshal.
+func (p *Presence) Set(name string) {
+	if *p == nil {
+		*p = Presence{}
+	}
+	(*p)[name] = true
+}
+
+// isPresenceField reports whether field is the embedded partialmarshal.Presence.
+func isPresenceField(field reflect.StructField) bool {
+	return field.Anonymous && field.Type == reflect.TypeOf(Presence{})
+}
+
+// presenceOf returns the embedded Presence of the struct reflectedValue, or
+// nil when there is none or it is not tracking fields.
+func presenceOf(reflectedValue reflect.Value) Presence {
+	presenceField := reflectedValue.FieldByName("Presence")
+	if !presenceField.IsValid() || presenceField.Type() != reflect.TypeOf(Presence{}) {
+		return nil
+	}
+	return presenceField.Interface().(Presence)
+}
@@ -0,0 +1,176 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExamplePresence() {
+	// A struct type with partialmarshal.Presence included as an embedded type
+	type examplestruct struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+		Limit int    `json:"limit"`
+		Presence
+		Extra
+	}
+
+	var destination examplestruct
+	err := Unmarshal([]byte(`{"name": "gopher", "count": 0}`), &destination)
+	fmt.Println(err)
+	fmt.Println(destination.IsSet("count"))
+	fmt.Println(destination.IsSet("limit"))
+
+	// Only the fields that were present are written back.
+	JSONData, _ := Marshal(destination)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// true
+	// false
+	// {"count":0,"name":"gopher"}
+}
+
+func TestPresence(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		SubFieldTwo string `json:"sub_field_two"`
+		Presence
+	}
+	type testStruct struct {
+		FieldOne       string    `json:"field_one"`
+		FieldTwo       int       `json:"field_two"`
+		FieldSubStruct subStruct `json:"field_sub_struct"`
+		Presence
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		inPatch         []byte
+		inSet           []string
+		outPresence     Presence
+		outData         string
+	}{
+		{
+			"should record fields present in payload",
+			[]byte(`{"field_one": "value one", "field_two": 0, "field_three": 3}`),
+			nil,
+			nil,
+			Presence{"field_one": true, "field_two": true},
+			`{"field_one":"value one","field_two":0,"field_three":3}`,
+		},
+		{
+			"should record fields matched by field name under their JSON name",
+			[]byte(`{"FieldTwo": 2}`),
+			nil,
+			nil,
+			Presence{"field_two": true},
+			`{"field_two":2}`,
+		},
+		{
+			"should record fields present in substructs",
+			[]byte(`{"field_sub_struct": {"sub_field_two": ""}}`),
+			nil,
+			nil,
+			Presence{"field_sub_struct": true},
+			`{"field_sub_struct":{"sub_field_two":""}}`,
+		},
+		{
+			"should record fields set after unmarshaling",
+			[]byte(`{"field_one": "value one"}`),
+			nil,
+			[]string{"field_two"},
+			Presence{"field_one": true, "field_two": true},
+			`{"field_one":"value one","field_two":0}`,
+		},
+		{
+			"should update presence with merge patches",
+			[]byte(`{"field_one": "value one"}`),
+			[]byte(`{"field_one": null, "field_two": 3}`),
+			nil,
+			Presence{"field_one": false, "field_two": true},
+			`{"field_two":3}`,
+		},
+		{
+			"should not treat a Presence key in the payload as a field",
+			[]byte(`{"Presence": {"field_one": true}}`),
+			nil,
+			nil,
+			Presence{},
+			`{"Presence":{"field_one":true}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			assert.NoError(t, Unmarshal(tc.inData, &value))
+			if tc.inPatch != nil {
+				assert.NoError(t, MergePatch(&value, tc.inPatch))
+			}
+			for _, name := range tc.inSet {
+				value.Set(name)
+			}
+			assert.Equal(t, tc.outPresence, value.Presence)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestPresenceNestedAndInline(t *testing.T) {
+	type inlineStruct struct {
+		InlineOne string `json:"inline_one"`
+		InlineTwo string `json:"inline_two"`
+		Presence
+	}
+	type testStruct struct {
+		AuthorName string       `partialmarshal:"meta.author.name"`
+		AuthorID   int          `partialmarshal:"meta.author.id"`
+		Inline     inlineStruct `partialmarshal:",inline"`
+		Presence
+	}
+
+	var value testStruct
+	err := Unmarshal([]byte(`{"meta": {"author": {"name": "Gopher"}}, "inline_one": "one"}`), &value)
+	assert.NoError(t, err)
+
+	// Fields with nested keys are recorded by their dotted path
+	assert.True(t, value.IsSet("meta.author.name"))
+	assert.False(t, value.IsSet("meta.author.id"))
+	assert.False(t, value.IsSet("meta"))
+
+	// The fields of inline structs are recorded by the inline struct
+	assert.False(t, value.IsSet("inline_one"))
+	assert.True(t, value.Inline.IsSet("inline_one"))
+	assert.False(t, value.Inline.IsSet("inline_two"))
+
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"meta":{"author":{"name":"Gopher"}},"inline_one":"one"}`, string(result))
+}
+
+func TestPresenceNotTracking(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+		FieldTwo int    `json:"field_two"`
+		Presence
+	}
+	value := testStruct{FieldOne: "value one"}
+	assert.False(t, value.IsSet("field_one"))
+
+	// Values that were not unmarshaled emit every field
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"value one","field_two":0}`, string(result))
+
+	value.Set("field_one")
+	result, err = Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"value one"}`, string(result))
+}
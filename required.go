@@ -0,0 +1,115 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// RequiredError lists the JSON Pointers of the keys that are missing for
+// fields tagged `partialmarshal:"required"`, in the whole document.
+//
+// The value is still decoded when this error is returned.
+type RequiredError struct {
+	Paths []string
+}
+
+func (e *RequiredError) Error() string {
+	paths := append([]string{}, e.Paths...)
+	sort.Strings(paths)
+	for i, path := range paths {
+		paths[i] = strconv.Quote(path)
+	}
+	return "partialmarshal: missing required keys: " + strings.Join(paths, ", ")
+}
+
+// pathsBelow returns the paths of the error as seen from the parent of the
+// value that was decoded from the key or index at the path tokens.
+func (e *RequiredError) pathsBelow(tokens ...string) []string {
+	paths := make([]string, 0, len(e.Paths))
+	for _, path := range e.Paths {
+		paths = append(paths, formatPointer(tokens)+path)
+	}
+	return paths
+}
+
+// asRequiredError returns err as a *RequiredError, or nil when it is another
+// kind of error.
+func asRequiredError(err error) *RequiredError {
+	var requiredErr *RequiredError
+	if errors.As(err, &requiredErr) {
+		return requiredErr
+	}
+	return nil
+}
+
+// isRequiredField reports whether field is tagged `partialmarshal:"required"`.
+func isRequiredField(field reflect.StructField) bool {
+	tag, _ := parseFieldTag(field)
+	return tag.required
+}
+
+// setDefault sets the field value to the default of its tag, if it has one.
+// A struct field without a default has the defaults of its own fields set.
+func setDefault(value reflect.Value, field reflect.StructField) error {
+	tag, _ := parseFieldTag(field)
+	if tag.defaultValue != nil {
+		defaultValue, err := parseDefault(*tag.defaultValue, field.Type)
+		if err != nil {
+			return fmt.Errorf("partialmarshal: invalid default %q for field %s: %v", *tag.defaultValue, field.Name, err)
+		}
+		value.Set(defaultValue)
+		return nil
+	}
+	if field.Type.Kind() != reflect.Struct || reflect.PtrTo(field.Type).Implements(unmarshalerType) {
+		return nil
+	}
+	for i := 0; i < field.Type.NumField(); i++ {
+		if field.Type.Field(i).PkgPath != "" {
+			continue
+		}
+		err := setDefault(value.Field(i), field.Type.Field(i))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDefault returns the value of type t that the default option of a
+// field tag holds. Strings are taken as they are, durations are parsed by
+// time.ParseDuration, and other types are decoded from a JSON literal.
+func parseDefault(value string, t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t == durationType:
+		duration, err := time.ParseDuration(value)
+		return reflect.ValueOf(duration), err
+	case t.Kind() == reflect.Ptr:
+		elem, err := parseDefault(value, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		pointer := reflect.New(t.Elem())
+		pointer.Elem().Set(elem)
+		return pointer, nil
+	case t.Implements(optionalValueType):
+		elem, err := parseDefault(value, t.Field(0).Type)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		optional := reflect.New(t).Elem()
+		optional.FieldByName("Value").Set(elem)
+		optional.FieldByName("Set").SetBool(true)
+		return optional, nil
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(value).Convert(t), nil
+	}
+	return decodeValue(json.RawMessage(value), t)
+}
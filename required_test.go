@@ -0,0 +1,162 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleRequiredError() {
+	// A struct type with required fields and defaults
+	type address struct {
+		City string `json:"city" partialmarshal:"required"`
+	}
+	type examplestruct struct {
+		Name      string        `json:"name" partialmarshal:"required"`
+		Timeout   time.Duration `json:"timeout" partialmarshal:"default=30s"`
+		Addresses []address     `json:"addresses"`
+		Extra
+	}
+
+	var destination examplestruct
+	err := Unmarshal([]byte(`{"addresses": [{"city": "Gopher City"}, {"zip": "12345"}]}`), &destination)
+	fmt.Println(err)
+	fmt.Println(destination.Timeout)
+
+	// Output:
+	// partialmarshal: missing required keys: "/addresses/1/city", "/name"
+	// 30s
+}
+
+func TestUnmarshalRequired(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one" partialmarshal:"required"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string              `json:"field_one" partialmarshal:"required"`
+		FieldTwo        *string             `json:"field_two" partialmarshal:"required"`
+		FieldSubStruct  subStruct           `json:"field_sub_struct"`
+		FieldSubStructs []subStruct         `json:"field_sub_structs"`
+		FieldSubPtr     *subStruct          `json:"field_sub_ptr"`
+		FieldOpt        Optional[subStruct] `json:"field_opt"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should accept present required keys, even null ones",
+			[]byte(`{"field_one": "one", "field_two": null, "field_sub_struct": {"sub_field_one": "sub one"}}`),
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should report every missing required key",
+			[]byte(`{"field_sub_struct": {"sub_field_one": "sub one"}}`),
+			`partialmarshal: missing required keys: "/field_one", "/field_two"`,
+		},
+		{
+			"should report missing required keys at every nesting level",
+			[]byte(`{
+				"field_one": "one",
+				"field_two": "two",
+				"field_sub_struct": {},
+				"field_sub_structs": [{"sub_field_one": "sub one"}, {"a/b": 1}],
+				"field_sub_ptr": {},
+				"field_opt": {}
+			}`),
+			`partialmarshal: missing required keys: "/field_opt/sub_field_one", "/field_sub_ptr/sub_field_one", "/field_sub_struct/sub_field_one", "/field_sub_structs/1/sub_field_one"`,
+		},
+		{
+			"should report decoding errors instead of missing keys",
+			[]byte(`{"field_one": 1}`),
+			"json: cannot unmarshal number into Go value of type string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	// The value is still decoded, extra payloads included
+	value, err := UnmarshalAs[testStruct]([]byte(`{"field_one": "one", "field_sub_structs": [{"a": 1}], "field_sub_ptr": {"sub_field_one": "sub one", "y": 1}, "b": 2}`))
+	assert.EqualError(t, err, `partialmarshal: missing required keys: "/field_sub_structs/0/sub_field_one", "/field_two"`)
+	assert.Equal(t, "one", value.FieldOne)
+	assert.Equal(t, Extra{"a": json.RawMessage(`1`)}, value.FieldSubStructs[0].Extra)
+	assert.Equal(t, &subStruct{"sub one", Extra{"y": json.RawMessage(`1`)}}, value.FieldSubPtr)
+	assert.Equal(t, Extra{"b": json.RawMessage(`2`)}, value.Extra)
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one" partialmarshal:"default=sub one"`
+	}
+	type testStruct struct {
+		FieldString   string            `json:"field_string" partialmarshal:"default=a,b"`
+		FieldInt      int               `json:"field_int" partialmarshal:"default=42"`
+		FieldFloat    float64           `json:"field_float" partialmarshal:"default=1.5"`
+		FieldBool     bool              `json:"field_bool" partialmarshal:"default=true"`
+		FieldDuration time.Duration     `json:"field_duration" partialmarshal:"default=1m30s"`
+		FieldSlice    []int             `json:"field_slice" partialmarshal:"default=[1,2]"`
+		FieldMap      map[string]string `json:"field_map" partialmarshal:"default={\"a\":\"b\"}"`
+		FieldPointer  *int              `json:"field_pointer" partialmarshal:"default=7"`
+		FieldOpt      Optional[string]  `json:"field_opt" partialmarshal:"default=opt"`
+		FieldSub      subStruct         `json:"field_sub"`
+		FieldSubPtr   *subStruct        `json:"field_sub_ptr"`
+		FieldSubs     []subStruct       `json:"field_subs"`
+	}
+	seven := 7
+
+	// Missing keys are set to their default at every nesting level
+	var value testStruct
+	err := Unmarshal([]byte(`{"field_sub_ptr": {}, "field_subs": [{}]}`), &value)
+	assert.NoError(t, err)
+	assert.Equal(t, testStruct{
+		FieldString:   "a,b",
+		FieldInt:      42,
+		FieldFloat:    1.5,
+		FieldBool:     true,
+		FieldDuration: 90 * time.Second,
+		FieldSlice:    []int{1, 2},
+		FieldMap:      map[string]string{"a": "b"},
+		FieldPointer:  &seven,
+		FieldOpt:      NewOptional("opt"),
+		FieldSub:      subStruct{"sub one"},
+		FieldSubPtr:   &subStruct{"sub one"},
+		FieldSubs:     []subStruct{{"sub one"}},
+	}, value)
+
+	// Present keys are kept, even null ones
+	value = testStruct{}
+	err = Unmarshal([]byte(`{"field_string": "", "field_int": null, "field_opt": null, "field_sub": {"sub_field_one": "one"}}`), &value)
+	assert.NoError(t, err)
+	assert.Equal(t, "", value.FieldString)
+	assert.Equal(t, 0, value.FieldInt)
+	assert.Equal(t, NewNull[string](), value.FieldOpt)
+	assert.Equal(t, "one", value.FieldSub.SubFieldOne)
+
+	// Invalid defaults are reported when they are needed
+	var invalid struct {
+		FieldInt      int           `partialmarshal:"default=many"`
+		FieldDuration time.Duration `partialmarshal:"default=soon"`
+	}
+	err = Unmarshal([]byte(`{"FieldDuration": 1}`), &invalid)
+	assert.EqualError(t, err, `partialmarshal: invalid default "many" for field FieldInt: invalid character 'm' looking for beginning of value`)
+	err = Unmarshal([]byte(`{"FieldInt": 1}`), &invalid)
+	assert.EqualError(t, err, `partialmarshal: invalid default "soon" for field FieldDuration: time: invalid duration "soon"`)
+}
@@ -0,0 +1,436 @@
+package partialmarshal
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema dialect of the schemas produced by Schema.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema returns a JSON Schema (draft 2020-12) describing the JSON encoding
+// of the type of v, as written by Marshal and read by Unmarshal.
+//
+// Object properties are named the way Marshal writes fields. Fields that are
+// pointers, Optionals or tagged omitempty are optional unless they are tagged
+// `partialmarshal:"required"`, and all the fields of a struct that embeds
+// Presence are optional. Pointers, other than the one to the value itself,
+// Optionals, including Nullables, slices and maps also allow null. Fields with
+// nested keys are described within the schemas of their intermediate objects.
+// Structs that embed Extra allow additional properties, structs that embed
+// ExtraOf allow additional properties of its value type, and other structs
+// allow none; extra storage written under an envelope key is described by the
+// object of that property instead. Extra rules registered by
+// RegisterExtraRules are described by patternProperties, and extensions
+// registered by RegisterExtension by optional properties. Structs, slices and
+// maps are described in $defs and referenced where they are used.
+func Schema(v interface{}) ([]byte, error) {
+	document := map[string]interface{}{"$schema": schemaDialect}
+	if v == nil {
+		return json.Marshal(document)
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	builder := &schemaBuilder{defs: map[string]interface{}{}, names: map[reflect.Type]string{}, types: map[string]reflect.Type{}}
+	root, err := builder.schemaOf(t, "Root")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range root {
+		document[key] = value
+	}
+	if len(builder.defs) > 0 {
+		document["$defs"] = builder.defs
+	}
+	return json.Marshal(document)
+}
+
+// schemaBuilder collects the $defs of a schema.
+type schemaBuilder struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string // $defs names by type
+	types map[string]reflect.Type // types by $defs name
+}
+
+// schemaOf returns the schema of values of type t. The hint names the $defs
+// entries of unnamed types.
+func (b *schemaBuilder) schemaOf(t reflect.Type, hint string) (map[string]interface{}, error) {
+	if t.Kind() == reflect.Ptr {
+		value, err := b.schemaOf(t.Elem(), hint)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(value), nil
+	}
+
+	// 1. Types with a JSON encoding of their own
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case t.Implements(optionalValueType):
+		value, err := b.schemaOf(t.Field(0).Type, hint)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(value), nil
+	case t.Implements(documentType):
+		return b.documentSchema(t, hint)
+	case t.Kind() == reflect.Struct && hasExtraStorage(t):
+		// Structs with generated methods are still described by their fields
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		return map[string]interface{}{}, nil
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}, nil
+	}
+
+	// 2. Types described by their kind
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Slice, reflect.Map:
+		// Nil slices and maps are written as null
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return nullable(map[string]interface{}{"type": "string", "contentEncoding": "base64"}), nil
+		}
+		reference, err := b.reference(t, hint)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(reference), nil
+	case reflect.Array, reflect.Struct:
+		return b.reference(t, hint)
+	}
+	return nil, &json.UnsupportedTypeError{Type: t}
+}
+
+// documentSchema returns the schema of the Document type t, which is the
+// schema of its value, except that a struct value without extra storage of
+// its own allows the additional properties kept in the Document.
+func (b *schemaBuilder) documentSchema(t reflect.Type, hint string) (map[string]interface{}, error) {
+	valueType := t.Field(0).Type
+	structType := inlineType(valueType)
+	if structType == nil || hasExtraStorage(structType) {
+		return b.schemaOf(valueType, hint)
+	}
+	reference, err := b.reference(t, hint)
+	if err != nil || valueType.Kind() != reflect.Ptr {
+		return reference, err
+	}
+	return nullable(reference), nil
+}
+
+// nullable returns a schema that allows null along with the values allowed
+// by schema.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if len(schema) == 0 {
+		// The empty schema allows null already
+		return schema
+	}
+	if alternatives, ok := schema["anyOf"].([]interface{}); ok && len(schema) == 1 {
+		for _, alternative := range alternatives {
+			if alternative, ok := alternative.(map[string]interface{}); ok && len(alternative) == 1 && alternative["type"] == "null" {
+				return schema
+			}
+		}
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
+
+// reference returns a reference to the $defs entry of t, adding the entry
+// when it is missing.
+func (b *schemaBuilder) reference(t reflect.Type, hint string) (map[string]interface{}, error) {
+	name, found := b.names[t]
+	if !found {
+		name = schemaName(t, hint)
+		for suffix := 2; b.types[name] != nil; suffix++ {
+			name = schemaName(t, hint) + strconv.Itoa(suffix)
+		}
+		b.names[t] = name
+		b.types[name] = t
+
+		definition, err := b.definition(t, name)
+		if err != nil {
+			return nil, err
+		}
+		b.defs[name] = definition
+	}
+	return map[string]interface{}{"$ref": "#" + formatPointer([]string{"$defs", name})}, nil
+}
+
+// schemaName returns the $defs name of t, based on hint for unnamed structs.
+func schemaName(t reflect.Type, hint string) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(documentType) {
+		return schemaName(t.Field(0).Type, hint) + "Document"
+	}
+	if t.Name() != "" && t.PkgPath() == "" {
+		// Predeclared types such as int
+		return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return schemaName(t.Elem(), hint) + "List"
+	case reflect.Map:
+		return schemaName(t.Elem(), hint) + "Map"
+	case reflect.Interface:
+		return "Any"
+	}
+	return hint
+}
+
+// definition returns the $defs entry of the array, slice, map or struct type t.
+func (b *schemaBuilder) definition(t reflect.Type, name string) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, err := b.schemaOf(t.Elem(), name+"Item")
+		if err != nil {
+			return nil, err
+		}
+		definition := map[string]interface{}{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			definition["minItems"] = t.Len()
+			definition["maxItems"] = t.Len()
+		}
+		return definition, nil
+	case reflect.Map:
+		values, err := b.schemaOf(t.Elem(), name+"Value")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	}
+	if t.Implements(documentType) {
+		definition, err := b.structDefinition(inlineType(t.Field(0).Type), name)
+		if err != nil {
+			return nil, err
+		}
+		definition["additionalProperties"] = true
+		return definition, nil
+	}
+	return b.structDefinition(t, name)
+}
+
+// structDefinition returns the $defs entry of the struct type t.
+func (b *schemaBuilder) structDefinition(t reflect.Type, name string) (map[string]interface{}, error) {
+	err := checkTags(t)
+	if err != nil {
+		return nil, err
+	}
+	properties := map[string]interface{}{}
+	required := []string{}
+	var additionalProperties interface{} = false
+	tracksPresence := false
+	naming := namingFor(t)
+	var nested []nestedValue
+	var nestedRequired []bool
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch {
+		case isExtraField(field):
+			additionalProperties = true
+			continue
+		case isExtraOfField(field):
+			values, err := b.schemaOf(field.Type.Elem(), name+"Extra")
+			if err != nil {
+				return nil, err
+			}
+			additionalProperties = values
+			continue
+		case isPresenceField(field):
+			tracksPresence = true
+			continue
+		case isExtensionsField(field):
+			continue
+		case field.PkgPath != "" || isSkipped(field):
+			continue
+		case isInlineField(field):
+			// The properties of inline structs are properties of this object
+			inlined, err := b.structDefinition(inlineType(field.Type), name+field.Name)
+			if err != nil {
+				return nil, err
+			}
+			for key, property := range inlined["properties"].(map[string]interface{}) {
+				if _, found := properties[key]; !found {
+					properties[key] = property
+				}
+			}
+			if inlinedRequired, found := inlined["required"]; found && field.Type.Kind() != reflect.Ptr {
+				required = append(required, inlinedRequired.([]string)...)
+			}
+			continue
+		}
+
+		property, err := b.schemaOf(field.Type, name+field.Name)
+		if err != nil {
+			return nil, err
+		}
+		_, options := parseTag(field.Tag.Get("json"))
+		optional := field.Type.Kind() == reflect.Ptr || field.Type.Implements(optionalValueType) || hasTagOption(options, "omitempty")
+		if path := fieldPath(field, naming); len(path) > 1 {
+			nested = append(nested, nestedValue{path: path, value: property})
+			nestedRequired = append(nestedRequired, !optional || isRequiredField(field))
+			continue
+		}
+		properties[jsonName(field, naming)] = property
+		if !optional || isRequiredField(field) {
+			required = append(required, jsonName(field, naming))
+		}
+	}
+
+	// The properties of nested keys are described within their intermediate
+	// objects, which allow other properties when the struct keeps them
+	envelope := envelopeKey(t)
+	for i, nestedField := range nested {
+		required = nestProperty(properties, required, nestedField, nestedRequired[i] && !tracksPresence, additionalProperties != false && envelope == "")
+	}
+
+	// The extra payload is described by the object itself, or by the object
+	// under its envelope key
+	definition := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	extraSchema := definition
+	if envelope != "" {
+		extraSchema = map[string]interface{}{"type": "object"}
+		properties[envelope] = extraSchema
+		definition["additionalProperties"] = false
+	}
+	extraSchema["additionalProperties"] = additionalProperties
+	if extensions := registeredExtensions(t); len(extensions) > 0 && embedsExtensions(t) {
+		// Registered extensions are optional properties among the extra keys
+		extensionProperties, _ := extraSchema["properties"].(map[string]interface{})
+		if extensionProperties == nil {
+			extensionProperties = map[string]interface{}{}
+			extraSchema["properties"] = extensionProperties
+		}
+		for key, extensionType := range extensions {
+			if _, found := extensionProperties[key]; found {
+				continue
+			}
+			property, err := b.schemaOf(extensionType, name+"Extension")
+			if err != nil {
+				return nil, err
+			}
+			extensionProperties[key] = property
+		}
+	}
+	if rules := registeredExtraRules(t); len(rules) > 0 {
+		// Extra keys must match a rule, so those matching none are rejected
+		extraSchema["patternProperties"] = patternProperties(rules, additionalProperties)
+		extraSchema["additionalProperties"] = false
+	}
+	if len(required) > 0 && !tracksPresence {
+		definition["required"] = required
+	}
+	return definition, nil
+}
+
+// nestProperty adds the property of the nested key at nestedField.path to
+// properties, creating the schemas of the intermediate objects on the way,
+// and returns the required properties with the first intermediate object
+// added when the key is required.
+func nestProperty(properties map[string]interface{}, required []string, nestedField nestedValue, isRequired, allowsOthers bool) []string {
+	head := nestedField.path[0]
+	if isRequired && !containsKey(required, head) {
+		required = append(required, head)
+	}
+	if len(nestedField.path) == 1 {
+		properties[head] = nestedField.value
+		return required
+	}
+
+	intermediate, _ := properties[head].(map[string]interface{})
+	if _, isIntermediate := intermediate["properties"].(map[string]interface{}); !isIntermediate {
+		intermediate = map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{},
+			"additionalProperties": allowsOthers,
+		}
+		properties[head] = intermediate
+	}
+	intermediateRequired, _ := intermediate["required"].([]string)
+	nestedField.path = nestedField.path[1:]
+	intermediateRequired = nestProperty(intermediate["properties"].(map[string]interface{}), intermediateRequired, nestedField, isRequired, allowsOthers)
+	if len(intermediateRequired) > 0 {
+		intermediate["required"] = intermediateRequired
+	}
+	return required
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// patternProperties returns the patternProperties of a struct with the extra
+// rules, where values is the schema of the values of its extra storage.
+func patternProperties(rules []extraRule, values interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for _, rule := range rules {
+		schemas := []interface{}{}
+		if values, ok := values.(map[string]interface{}); ok {
+			schemas = append(schemas, values)
+		}
+		if len(rule.kinds) == 1 {
+			schemas = append(schemas, map[string]interface{}{"type": rule.kinds[0]})
+		} else if len(rule.kinds) > 1 {
+			schemas = append(schemas, map[string]interface{}{"type": rule.kinds})
+		}
+		if existing, found := properties[rule.pattern.String()]; found {
+			// Rules with the same pattern all apply
+			schemas = append([]interface{}{existing}, schemas...)
+		}
+
+		switch len(schemas) {
+		case 0:
+			properties[rule.pattern.String()] = map[string]interface{}{}
+		case 1:
+			properties[rule.pattern.String()] = schemas[0]
+		default:
+			properties[rule.pattern.String()] = map[string]interface{}{"allOf": schemas}
+		}
+	}
+	return properties
+}
+
+// hasTagOption reports whether the comma-separated tag options contain option.
+func hasTagOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
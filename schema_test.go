@@ -0,0 +1,251 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleSchema() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo int `json:"example_field_two,omitempty"`
+		Extra
+	}
+
+	JSONSchema, _ := Schema(examplestruct{})
+	fmt.Println(string(JSONSchema))
+
+	// Output:
+	// {"$defs":{"examplestruct":{"additionalProperties":true,"properties":{"ExampleFieldOne":{"type":"string"},"example_field_two":{"type":"integer"}},"required":["ExampleFieldOne"],"type":"object"}},"$ref":"#/$defs/examplestruct","$schema":"https://json-schema.org/draft/2020-12/schema"}
+}
+
+type schemaNode struct {
+	Value    string        `json:"value"`
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+func TestSchema(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+	}
+	type trackedStruct struct {
+		FieldOne string `json:"field_one"`
+		Presence
+	}
+	testCases := []struct {
+		testDescription string
+		inValue         interface{}
+		outSchema       string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should describe scalar types",
+			struct {
+				FieldBool    bool              `json:"field_bool"`
+				FieldInt     int64             `json:"field_int"`
+				FieldFloat   float32           `json:"field_float"`
+				FieldBytes   []byte            `json:"field_bytes"`
+				FieldTime    time.Time         `json:"field_time"`
+				FieldAny     interface{}       `json:"field_any"`
+				FieldRaw     json.RawMessage   `json:"field_raw"`
+				FieldPointer *string           `json:"field_pointer"`
+				FieldOpt     Optional[float64] `json:"field_opt"`
+				FieldSkipped string            `json:"-"`
+				fieldPrivate string
+			}{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/Root",
+				"$defs": {
+					"Root": {
+						"type": "object",
+						"properties": {
+							"field_bool": {"type": "boolean"},
+							"field_int": {"type": "integer"},
+							"field_float": {"type": "number"},
+							"field_bytes": {"anyOf": [{"type": "string", "contentEncoding": "base64"}, {"type": "null"}]},
+							"field_time": {"type": "string", "format": "date-time"},
+							"field_any": {},
+							"field_raw": {},
+							"field_pointer": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+							"field_opt": {"anyOf": [{"type": "number"}, {"type": "null"}]}
+						},
+						"required": ["field_bool", "field_int", "field_float", "field_bytes", "field_time", "field_any", "field_raw"],
+						"additionalProperties": false
+					}
+				}
+			}`,
+			"",
+		},
+		{
+			"should describe nested structs, slices and maps in defs",
+			&struct {
+				FieldSubStruct  subStruct              `json:"field_sub_struct"`
+				FieldSubStructs []subStruct            `json:"field_sub_structs,omitempty"`
+				FieldMap        map[string][2]int      `json:"field_map"`
+				FieldExtraOf    struct{ ExtraOf[int] } `json:"field_extra_of"`
+				Extra
+			}{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/Root",
+				"$defs": {
+					"Root": {
+						"type": "object",
+						"properties": {
+							"field_sub_struct": {"$ref": "#/$defs/subStruct"},
+							"field_sub_structs": {"anyOf": [{"$ref": "#/$defs/subStructList"}, {"type": "null"}]},
+							"field_map": {"anyOf": [{"$ref": "#/$defs/IntListMap"}, {"type": "null"}]},
+							"field_extra_of": {"$ref": "#/$defs/RootFieldExtraOf"}
+						},
+						"required": ["field_sub_struct", "field_map", "field_extra_of"],
+						"additionalProperties": true
+					},
+					"subStruct": {
+						"type": "object",
+						"properties": {"sub_field_one": {"type": "string"}},
+						"required": ["sub_field_one"],
+						"additionalProperties": false
+					},
+					"subStructList": {"type": "array", "items": {"$ref": "#/$defs/subStruct"}},
+					"IntListMap": {"type": "object", "additionalProperties": {"$ref": "#/$defs/IntList"}},
+					"IntList": {"type": "array", "items": {"type": "integer"}, "minItems": 2, "maxItems": 2},
+					"RootFieldExtraOf": {"type": "object", "properties": {}, "additionalProperties": {"type": "integer"}}
+				}
+			}`,
+			"",
+		},
+		{
+			"should reference recursive types",
+			schemaNode{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/schemaNode",
+				"$defs": {
+					"schemaNode": {
+						"type": "object",
+						"properties": {
+							"value": {"type": "string"},
+							"children": {"anyOf": [{"$ref": "#/$defs/schemaNodeList"}, {"type": "null"}]}
+						},
+						"required": ["value"],
+						"additionalProperties": false
+					},
+					"schemaNodeList": {"type": "array", "items": {"anyOf": [{"$ref": "#/$defs/schemaNode"}, {"type": "null"}]}}
+				}
+			}`,
+			"",
+		},
+		{
+			"should make every field optional when presence is tracked",
+			trackedStruct{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/trackedStruct",
+				"$defs": {
+					"trackedStruct": {
+						"type": "object",
+						"properties": {"field_one": {"type": "string"}},
+						"additionalProperties": false
+					}
+				}
+			}`,
+			"",
+		},
+		{
+			"should require optional fields tagged required",
+			struct {
+				FieldOne *string       `json:"field_one" partialmarshal:"required"`
+				FieldTwo Optional[int] `json:"field_two,omitempty" partialmarshal:"required"`
+				FieldSix Optional[int] `json:"field_six"`
+			}{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/Root",
+				"$defs": {
+					"Root": {
+						"type": "object",
+						"properties": {
+							"field_one": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+							"field_two": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+							"field_six": {"anyOf": [{"type": "integer"}, {"type": "null"}]}
+						},
+						"required": ["field_one", "field_two"],
+						"additionalProperties": false
+					}
+				}
+			}`,
+			"",
+		},
+		{
+			"should allow null for pointer fields and elements",
+			struct {
+				FieldOne   *int               `json:"field_one"`
+				FieldTwo   **subStruct        `json:"field_two"`
+				FieldThree []*int             `json:"field_three"`
+				FieldFour  Optional[*int]     `json:"field_four"`
+				FieldFive  *interface{}       `json:"field_five"`
+				FieldSix   map[string]*string `json:"field_six"`
+			}{},
+			`{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"$ref": "#/$defs/Root",
+				"$defs": {
+					"Root": {
+						"type": "object",
+						"properties": {
+							"field_one": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+							"field_two": {"anyOf": [{"$ref": "#/$defs/subStruct"}, {"type": "null"}]},
+							"field_three": {"anyOf": [{"$ref": "#/$defs/IntList"}, {"type": "null"}]},
+							"field_four": {"anyOf": [{"type": "integer"}, {"type": "null"}]},
+							"field_five": {},
+							"field_six": {"anyOf": [{"$ref": "#/$defs/StringMap"}, {"type": "null"}]}
+						},
+						"required": ["field_three", "field_six"],
+						"additionalProperties": false
+					},
+					"subStruct": {
+						"type": "object",
+						"properties": {"sub_field_one": {"type": "string"}},
+						"required": ["sub_field_one"],
+						"additionalProperties": false
+					},
+					"IntList": {"type": "array", "items": {"anyOf": [{"type": "integer"}, {"type": "null"}]}},
+					"StringMap": {"type": "object", "additionalProperties": {"anyOf": [{"type": "string"}, {"type": "null"}]}}
+				}
+			}`,
+			"",
+		},
+		{
+			"should describe non-struct values",
+			"",
+			`{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "string"}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when provided with unsupported types",
+			struct{ FieldOne chan int }{},
+			``,
+			"json: unsupported type: chan int",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			result, err := Schema(tc.inValue)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outSchema, string(result))
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package partialmarshal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldTag holds the options of the partialmarshal struct tag of a field.
+//
+// The tag is read before the json tag, so that the key of a field in the
+// partialmarshal view may differ from its key for encoding/json. It is a
+// comma-separated list of elements:
+//
+//	partialmarshal:"name,required,aliases=a|b,default=value"
+//	partialmarshal:"-"
+//	partialmarshal:",extra"
+//	partialmarshal:",inline"
+//	partialmarshal:"envelope=key"
+//
+// The first element is the key of the field, unless it is spelled like an
+// option, and an empty key falls back to the json tag. The key "-" alone
+// leaves the field out. Keys with dots, such as "meta.author.name", match
+// the key nested in the intermediate objects of the path. The options are:
+//
+//	required         the key must be present when decoding
+//	default=value    the value stored when the key is missing; it takes the
+//	                 rest of the tag, since JSON literals may contain commas
+//	aliases=a|b      other keys or paths that match the field, by precedence
+//	extra            the field, of type Extra or ExtraOf, stores the extra
+//	                 payload like an embedded Extra does
+//	envelope=key     the extra storage field is written as an object under
+//	                 the key, whose keys are kept in it when decoding
+//	inline           the fields of the struct or pointer to struct field are
+//	                 matched and written in the JSON object of its parent
+type fieldTag struct {
+	name         string   // key of the field, or "" to use the json tag
+	skip         bool     // whether the field is left out
+	required     bool     // whether the key must be present
+	defaultValue *string  // value stored when the key is missing, if any
+	aliases      []string // other keys that match the field, by precedence
+	extra        bool     // whether the field stores the extra payload
+	inline       bool     // whether the fields of the field are inlined
+	envelope     string   // key that the extra payload is written under, if any
+}
+
+// tagOptions are the options of the partialmarshal tag without a value.
+var tagOptions = map[string]bool{"required": true, "extra": true, "inline": true}
+
+// parsedTagKey identifies the parts of a field that its partialmarshal tag
+// options depend on.
+type parsedTagKey struct {
+	fieldType reflect.Type
+	tag       reflect.StructTag
+	anonymous bool
+}
+
+// parsedTag is the result of parseFieldTag for a parsedTagKey.
+type parsedTag struct {
+	tag fieldTag
+	err error
+}
+
+var parsedTags sync.Map // parsedTag by parsedTagKey
+
+// parseFieldTag returns the options of the partialmarshal tag of field. For
+// a malformed tag, it also returns an error describing why, along with the
+// options that could be parsed.
+//
+// The options are parsed once for each field type and struct tag, and must
+// not be modified by callers.
+func parseFieldTag(field reflect.StructField) (fieldTag, error) {
+	raw, found := field.Tag.Lookup("partialmarshal")
+	if !found {
+		return fieldTag{}, nil
+	}
+	key := parsedTagKey{fieldType: field.Type, tag: field.Tag, anonymous: field.Anonymous}
+	if parsed, found := parsedTags.Load(key); found {
+		return parsed.(parsedTag).tag, parsed.(parsedTag).err
+	}
+	tag, err := parseRawTag(raw, field)
+	parsedTags.Store(key, parsedTag{tag: tag, err: err})
+	return tag, err
+}
+
+// parseRawTag parses raw, the partialmarshal tag of field.
+func parseRawTag(raw string, field reflect.StructField) (fieldTag, error) {
+	var tag fieldTag
+	if raw == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+
+	// 1. Read the elements of the tag
+	seen := map[string]bool{}
+	for i, rest := 0, raw; i == 0 || rest != ""; i++ {
+		element := rest
+		if strings.HasPrefix(element, "default=") {
+			rest = ""
+		} else if idx := strings.Index(rest, ","); idx != -1 {
+			element, rest = rest[:idx], rest[idx+1:]
+		} else {
+			rest = ""
+		}
+
+		option, value, hasValue := strings.Cut(element, "=")
+		if i == 0 && !hasValue && !tagOptions[element] {
+			tag.name = element
+			continue
+		}
+		if seen[option] {
+			return tag, fmt.Errorf("option %q is given more than once", option)
+		}
+		seen[option] = true
+
+		switch {
+		case option == "required" && !hasValue:
+			tag.required = true
+		case option == "extra" && !hasValue:
+			tag.extra = true
+		case option == "inline" && !hasValue:
+			tag.inline = true
+		case option == "envelope" && hasValue:
+			if value == "" {
+				return tag, errors.New("envelope must not be empty")
+			}
+			tag.envelope = value
+		case option == "default" && hasValue:
+			tag.defaultValue = &value
+		case option == "aliases" && hasValue:
+			for _, alias := range strings.Split(value, "|") {
+				if alias == "" {
+					return tag, errors.New("aliases must not be empty")
+				}
+				tag.aliases = append(tag.aliases, alias)
+			}
+		case element == "":
+			return tag, errors.New("options must not be empty")
+		default:
+			return tag, fmt.Errorf("unknown option %q", element)
+		}
+	}
+
+	// 2. Check the nested keys, the options against each other and the type
+	// of the field
+	for _, key := range append([]string{tag.name}, tag.aliases...) {
+		for _, token := range keyPath(key, tag) {
+			if token == "" && key != "" {
+				return tag, fmt.Errorf("key %q has an empty path segment", key)
+			}
+		}
+	}
+	isExtraType := false
+	if tag.extra || tag.envelope != "" {
+		isExtraType = field.Type == reflect.TypeOf(Extra{}) || field.Type.Implements(extraOfMapType)
+	}
+	others := len(seen)
+	if tag.envelope != "" {
+		others--
+	}
+	switch {
+	case tag.envelope != "" && (!isExtraType || !(field.Anonymous || tag.extra)):
+		return tag, errors.New("envelope requires an embedded Extra or ExtraOf field, or a field tagged extra")
+	case (tag.extra || tag.inline) && (tag.name != "" || others > 1):
+		return tag, errors.New("extra and inline cannot be combined with a key or other options")
+	case tag.extra && !isExtraType:
+		return tag, fmt.Errorf("extra requires a field of type partialmarshal.Extra or partialmarshal.ExtraOf, not %v", field.Type)
+	case tag.inline && inlineType(field.Type) == nil:
+		return tag, fmt.Errorf("inline requires a field of struct or pointer to struct type, not %v", field.Type)
+	}
+	return tag, nil
+}
+
+// inlineType returns the struct type whose fields are inlined for a field of
+// type t, or nil when t is neither a struct nor a pointer to a struct.
+func inlineType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// isInlineField reports whether field is tagged `partialmarshal:",inline"`.
+func isInlineField(field reflect.StructField) bool {
+	tag, err := parseFieldTag(field)
+	return err == nil && tag.inline
+}
+
+// TagError describes a malformed partialmarshal struct tag.
+type TagError struct {
+	Struct reflect.Type
+	Field  string
+	Reason string
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("partialmarshal: invalid partialmarshal tag on %v.%s: %s", e.Struct, e.Field, e.Reason)
+}
+
+// tagCheck is the result of checkTags for a struct type.
+type tagCheck struct {
+	err error
+}
+
+var checkedTags sync.Map // tagCheck by struct type
+
+// checkTags returns a *TagError for the first malformed partialmarshal tag
+// of the fields of the struct type t, including inline fields.
+func checkTags(t reflect.Type) error {
+	if check, found := checkedTags.Load(t); found {
+		return check.(tagCheck).err
+	}
+
+	var err error
+	for i := 0; i < t.NumField() && err == nil; i++ {
+		field := t.Field(i)
+		tag, tagErr := parseFieldTag(field)
+		if tagErr != nil {
+			err = &TagError{Struct: t, Field: field.Name, Reason: tagErr.Error()}
+		} else if tag.inline {
+			err = checkTags(inlineType(field.Type))
+		}
+	}
+	checkedTags.Store(t, tagCheck{err: err})
+	return err
+}
+
+// hasFieldTags reports whether a field of the struct type t has a
+// partialmarshal tag.
+func hasFieldTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, found := t.Field(i).Tag.Lookup("partialmarshal"); found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package partialmarshal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFieldTag(t *testing.T) {
+	stringPointer := func(s string) *string { return &s }
+	testCases := []struct {
+		testDescription string
+		inTag           reflect.StructTag
+		inType          reflect.Type
+		outTag          fieldTag
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should return no options without a tag",
+			`json:"field_one"`,
+			nil,
+			fieldTag{},
+			"",
+		},
+		{
+			"should parse the required option",
+			`partialmarshal:"required"`,
+			nil,
+			fieldTag{required: true},
+			"",
+		},
+		{
+			"should parse a default value",
+			`partialmarshal:"required,default=5s"`,
+			nil,
+			fieldTag{required: true, defaultValue: stringPointer("5s")},
+			"",
+		},
+		{
+			"should keep commas in a default value",
+			`partialmarshal:"default=[1,2],required"`,
+			nil,
+			fieldTag{defaultValue: stringPointer("[1,2],required")},
+			"",
+		},
+		{
+			"should parse aliases",
+			`partialmarshal:"aliases=full_name|fullName,required"`,
+			nil,
+			fieldTag{required: true, aliases: []string{"full_name", "fullName"}},
+			"",
+		},
+		{
+			"should parse an empty default value",
+			`partialmarshal:"default="`,
+			nil,
+			fieldTag{defaultValue: stringPointer("")},
+			"",
+		},
+		{
+			"should parse a key",
+			`partialmarshal:"field_one,required"`,
+			nil,
+			fieldTag{name: "field_one", required: true},
+			"",
+		},
+		{
+			"should skip fields tagged with a dash",
+			`partialmarshal:"-" json:"field_one"`,
+			nil,
+			fieldTag{skip: true},
+			"",
+		},
+		{
+			"should parse the extra option",
+			`partialmarshal:",extra"`,
+			reflect.TypeOf(Extra{}),
+			fieldTag{extra: true},
+			"",
+		},
+		{
+			"should parse the extra option without a comma",
+			`partialmarshal:"extra"`,
+			reflect.TypeOf(ExtraOf[int]{}),
+			fieldTag{extra: true},
+			"",
+		},
+		{
+			"should parse the inline option",
+			`partialmarshal:",inline"`,
+			reflect.TypeOf(&struct{}{}),
+			fieldTag{inline: true},
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for unknown options",
+			`partialmarshal:"field_one,requried"`,
+			nil,
+			fieldTag{name: "field_one"},
+			`unknown option "requried"`,
+		},
+		{
+			"should return error for options given more than once",
+			`partialmarshal:",required,required"`,
+			nil,
+			fieldTag{required: true},
+			`option "required" is given more than once`,
+		},
+		{
+			"should return error for empty options",
+			`partialmarshal:"field_one,,required"`,
+			nil,
+			fieldTag{name: "field_one"},
+			"options must not be empty",
+		},
+		{
+			"should return error for empty aliases",
+			`partialmarshal:"aliases=full_name||fullName"`,
+			nil,
+			fieldTag{aliases: []string{"full_name"}},
+			"aliases must not be empty",
+		},
+		{
+			"should return error for extra with a key",
+			`partialmarshal:"rest,extra"`,
+			reflect.TypeOf(Extra{}),
+			fieldTag{name: "rest", extra: true},
+			"extra and inline cannot be combined with a key or other options",
+		},
+		{
+			"should return error for extra on other types",
+			`partialmarshal:",extra"`,
+			reflect.TypeOf(map[string]interface{}{}),
+			fieldTag{extra: true},
+			"extra requires a field of type partialmarshal.Extra or partialmarshal.ExtraOf, not map[string]interface {}",
+		},
+		{
+			"should return error for inline on other types",
+			`partialmarshal:",inline"`,
+			reflect.TypeOf(""),
+			fieldTag{inline: true},
+			"inline requires a field of struct or pointer to struct type, not string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			if tc.inType == nil {
+				tc.inType = reflect.TypeOf("")
+			}
+			field := reflect.StructField{Name: "FieldOne", Type: tc.inType, Tag: tc.inTag}
+			tag, err := parseFieldTag(field)
+			assert.Equal(t, tc.outTag, tag)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseFieldTagCached(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `partialmarshal:"field_one,aliases=a|b"`
+		FieldTwo string `partialmarshal:"field_two,aliases=a|b,unknown"`
+	}
+	structType := reflect.TypeOf(testStruct{})
+
+	// The options of a field are parsed once and shared afterwards
+	first, err := parseFieldTag(structType.Field(0))
+	assert.NoError(t, err)
+	second, err := parseFieldTag(structType.Field(0))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, reflect.ValueOf(first.aliases).Pointer(), reflect.ValueOf(second.aliases).Pointer())
+
+	// So are the errors of malformed tags
+	_, err = parseFieldTag(structType.Field(1))
+	assert.EqualError(t, err, `unknown option "unknown"`)
+	_, err = parseFieldTag(structType.Field(1))
+	assert.EqualError(t, err, `unknown option "unknown"`)
+}
+
+func TestFieldTags(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `partialmarshal:"subfield_one"`
+	}
+	type inlineStruct struct {
+		FieldThree string `json:"field_three"`
+		FieldFour  int    `partialmarshal:"field_four,required"`
+	}
+	type testStruct struct {
+		FieldOne  string       `json:"fieldOne" partialmarshal:"field_one"`
+		FieldTwo  string       `json:"-" partialmarshal:"field_two"`
+		FieldSkip string       `json:"field_skip" partialmarshal:"-"`
+		Inline    inlineStruct `partialmarshal:",inline"`
+		InlinePtr *subStruct   `partialmarshal:",inline"`
+		Rest      Extra        `partialmarshal:",extra"`
+	}
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should match keys by the partialmarshal tag and inline fields",
+			[]byte(`{"field_one": "one", "field_two": "two", "field_three": "three", "field_four": 4, "subfield_one": "sub", "field_skip": "skip", "b": 1}`),
+			`{"field_one":"one","field_two":"two","field_three":"three","field_four":4,"subfield_one":"sub","field_skip":"skip","b":1}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for missing required keys of inline fields",
+			[]byte(`{"field_one": "one"}`),
+			``,
+			`partialmarshal: missing required keys: "/field_four"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, `"skip"`, string(value.Rest["field_skip"]))
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestFieldTagsNested(t *testing.T) {
+	type innerStruct struct {
+		FieldOne string  `partialmarshal:"renamed"`
+		FieldTwo *string `json:"field_two" partialmarshal:"required"`
+	}
+	type middleStruct struct {
+		Inner innerStruct `json:"inner"`
+	}
+	type outerStruct struct {
+		Middle  middleStruct   `json:"middle"`
+		Middles []middleStruct `json:"middles"`
+	}
+
+	// The tags of structs held by structs without tags of their own still apply
+	value := outerStruct{Middle: middleStruct{innerStruct{FieldOne: "one"}}, Middles: []middleStruct{{innerStruct{FieldOne: "two"}}}}
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"middle":{"inner":{"renamed":"one","field_two":null}},"middles":[{"inner":{"renamed":"two","field_two":null}}]}`, string(result))
+
+	var decoded outerStruct
+	err = Unmarshal(result, &decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decoded)
+}
+
+func TestFieldTagsExtraOf(t *testing.T) {
+	type testStruct struct {
+		FieldOne string       `json:"field_one"`
+		Counts   ExtraOf[int] `partialmarshal:",extra"`
+	}
+
+	var value testStruct
+	err := Unmarshal([]byte(`{"field_one": "one", "a": 1, "b": 2}`), &value)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, value.Counts["b"])
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"one","a":1,"b":2}`, string(result))
+}
+
+func TestFieldTagsInline(t *testing.T) {
+	type inlineStruct struct {
+		FieldTwo   string `json:"field_two"`
+		FieldThree int    `json:"field_three" partialmarshal:",required"`
+	}
+	type testStruct struct {
+		FieldOne string        `json:"field_one"`
+		Inline   *inlineStruct `partialmarshal:",inline"`
+		Extra
+	}
+
+	value := testStruct{FieldOne: "one"}
+	err := MergePatch(&value, []byte(`{"field_two": "two", "c": 3}`))
+	assert.NoError(t, err)
+	result, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":"one","field_two":"two","field_three":0,"c":3}`, string(result))
+
+	schema, err := Schema(testStruct{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(schema), `"field_two":{"type":"string"}`)
+	assert.Contains(t, string(schema), `"required":["field_one"]`)
+}
+
+func TestTagError(t *testing.T) {
+	type inlineStruct struct {
+		FieldTwo string `partialmarshal:"field_two,optional"`
+	}
+	type testStruct struct {
+		FieldOne string       `json:"field_one"`
+		Inline   inlineStruct `partialmarshal:",inline"`
+	}
+	msg := `partialmarshal: invalid partialmarshal tag on partialmarshal.inlineStruct.FieldTwo: unknown option "optional"`
+
+	var value testStruct
+	err := Unmarshal([]byte(`{"field_one": "one"}`), &value)
+	assert.EqualError(t, err, msg)
+	if assert.IsType(t, &TagError{}, err) {
+		assert.Equal(t, "FieldTwo", err.(*TagError).Field)
+	}
+
+	_, err = Marshal(value)
+	assert.EqualError(t, err, msg)
+	_, err = Schema(value)
+	assert.EqualError(t, err, msg)
+	err = MergePatch(&value, []byte(`{}`))
+	assert.EqualError(t, err, msg)
+}
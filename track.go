@@ -0,0 +1,93 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Snapshot - The JSON encoding of a value recorded by Track, against which
+// MarshalChanges reports the changes made to the value since.
+type Snapshot struct {
+	document interface{}
+}
+
+// Track returns a snapshot of the value pointed to by v, against which
+// MarshalChanges later reports the changes made to v. The snapshot is held
+// by the caller only, so it is released along with v.
+func Track(v interface{}) (Snapshot, error) {
+	if _, err := getReflectedValue(v); err != nil {
+		return Snapshot{}, err
+	}
+	document, err := marshalDocument(v)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{document: document}, nil
+}
+
+// MarshalChanges returns an RFC 7396 JSON Merge Patch document holding only
+// what changed in the value pointed to by v since snapshot was returned by
+// Track.
+//
+// Modified typed fields and added partialmarshal.Extra keys are written with
+// their new value, and removed Extra keys are written as null, at any
+// nesting depth. Arrays that changed are written as a whole, since a merge
+// patch cannot describe changes to individual elements. Keys added with a
+// null value are left out, since a merge patch reads null as a removal.
+func MarshalChanges(v interface{}, snapshot Snapshot) ([]byte, error) {
+	if snapshot.document == nil {
+		return nil, errors.New("partialmarshal: MarshalChanges called with a snapshot not returned by Track")
+	}
+
+	document, err := marshalDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	patch := mergePatchBetween(snapshot.document, document)
+	if patch == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(patch)
+}
+
+// mergePatchBetween returns the merge patch that turns the object a into
+// the object b, or nil when they are equal.
+func mergePatchBetween(a, b interface{}) map[string]interface{} {
+	aObject, _ := a.(map[string]interface{})
+	bObject, _ := b.(map[string]interface{})
+
+	patch := map[string]interface{}{}
+	for key := range aObject {
+		if _, found := bObject[key]; !found {
+			patch[key] = nil
+		}
+	}
+	for key, bValue := range bObject {
+		aValue, found := aObject[key]
+		if (found && documentsEqual(aValue, bValue)) || (!found && bValue == nil) {
+			// A key added with null would read as a removal
+			continue
+		}
+		_, aIsObject := aValue.(map[string]interface{})
+		_, bIsObject := bValue.(map[string]interface{})
+		if !bIsObject {
+			patch[key] = bValue
+			continue
+		}
+
+		// Objects are patched key by key, including new ones, whose nulls
+		// would read as removals too
+		nested := mergePatchBetween(aValue, bValue)
+		if nested == nil && aIsObject {
+			continue
+		}
+		if nested == nil {
+			nested = map[string]interface{}{}
+		}
+		patch[key] = nested
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	return patch
+}
@@ -0,0 +1,157 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleMarshalChanges() {
+	// A struct type with partialmarshal.Extra included as an embedded type
+	type examplestruct struct {
+		ExampleFieldOne string
+		ExampleFieldTwo string `json:"example_field_two"`
+		Extra
+	}
+
+	var destination examplestruct
+	Unmarshal([]byte(`{
+		"ExampleFieldOne": "value 1",
+		"example_field_two": "value 2",
+		"some_other_field": "some other value"
+	}`), &destination)
+
+	snapshot, _ := Track(&destination)
+
+	destination.ExampleFieldTwo = "new value 2"
+	delete(destination.Extra, "some_other_field")
+
+	JSONData, _ := MarshalChanges(&destination, snapshot)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// {"example_field_two":"new value 2","some_other_field":null}
+}
+
+func TestMarshalChanges(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+		Extra
+	}
+	type testStruct struct {
+		FieldOne        string      `json:"field_one"`
+		FieldTwo        int         `json:"field_two"`
+		FieldSubStruct  subStruct   `json:"field_sub_struct"`
+		FieldSubStructs []subStruct `json:"field_sub_structs"`
+		Extra
+	}
+	testCases := []struct {
+		testDescription string
+		inChange        func(value *testStruct)
+		outPatch        string
+	}{
+		{
+			"should return empty patch when nothing changed",
+			func(value *testStruct) {},
+			`{}`,
+		},
+		{
+			"should return modified typed fields",
+			func(value *testStruct) {
+				value.FieldTwo = 3
+			},
+			`{"field_two":3}`,
+		},
+		{
+			"should return added and removed extra keys",
+			func(value *testStruct) {
+				delete(value.Extra, "a")
+				value.Extra["c"] = []byte(`{"d":4}`)
+			},
+			`{"a":null,"c":{"d":4}}`,
+		},
+		{
+			"should return changes in nested extra content only",
+			func(value *testStruct) {
+				value.Extra["b"] = []byte(`{"x": 1, "y": 3}`)
+			},
+			`{"b":{"y":3}}`,
+		},
+		{
+			"should return changes of substructs at any depth",
+			func(value *testStruct) {
+				value.FieldSubStruct.SubFieldOne = "new one"
+				delete(value.FieldSubStruct.Extra, "sub_field_two")
+			},
+			`{"field_sub_struct":{"sub_field_one":"new one","sub_field_two":null}}`,
+		},
+		{
+			"should return changed arrays as a whole",
+			func(value *testStruct) {
+				value.FieldSubStructs[1].Extra["z"] = []byte(`true`)
+			},
+			`{"field_sub_structs":[{"sub_field_one":"one"},{"sub_field_one":"two","z":true}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			assert.NoError(t, Unmarshal([]byte(`{
+				"field_one": "one",
+				"field_two": 2,
+				"field_sub_struct": {"sub_field_one": "one", "sub_field_two": "two"},
+				"field_sub_structs": [{"sub_field_one": "one"}, {"sub_field_one": "two"}],
+				"a": 1,
+				"b": {"x": 1, "y": 2}
+			}`), &value))
+			snapshot, err := Track(&value)
+			assert.NoError(t, err)
+
+			tc.inChange(&value)
+			patch, err := MarshalChanges(&value, snapshot)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outPatch, string(patch))
+
+			// Applying the changes to an untouched copy should produce value
+			var original testStruct
+			assert.NoError(t, Unmarshal([]byte(`{
+				"field_one": "one",
+				"field_two": 2,
+				"field_sub_struct": {"sub_field_one": "one", "sub_field_two": "two"},
+				"field_sub_structs": [{"sub_field_one": "one"}, {"sub_field_one": "two"}],
+				"a": 1,
+				"b": {"x": 1, "y": 2}
+			}`), &original))
+			assert.NoError(t, MergePatch(&original, patch))
+			changes, err := Diff(original, value)
+			assert.NoError(t, err)
+			assert.Equal(t, Changes{}, changes)
+		})
+	}
+}
+
+func TestMarshalChangesErrors(t *testing.T) {
+	type testStruct struct {
+		FieldOne string `json:"field_one"`
+	}
+	value := testStruct{}
+
+	_, err := Track(value)
+	assert.EqualError(t, err, "json: Unmarshal(non-pointer partialmarshal.testStruct)")
+
+	_, err = MarshalChanges(&value, Snapshot{})
+	assert.EqualError(t, err, "partialmarshal: MarshalChanges called with a snapshot not returned by Track")
+}
+
+func TestMergePatchBetween(t *testing.T) {
+	var a, b interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"a": {"b": 1, "c": [1]}, "d": "e"}`), &a))
+	assert.NoError(t, json.Unmarshal([]byte(`{"a": {"b": 1, "c": [2], "g": null}, "f": null, "h": {"i": null, "j": 1}, "k": {"l": null}}`), &b))
+	patch, err := json.Marshal(mergePatchBetween(a, b))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":{"c":[2]},"d":null,"h":{"j":1},"k":{}}`, string(patch))
+	assert.Nil(t, mergePatchBetween(a, a))
+}
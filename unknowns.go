@@ -0,0 +1,146 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Unknowns holds the keys of a JSON document that match no field of the
+// value it was decoded into, indexed by the JSON Pointer of each key.
+type Unknowns map[string]json.RawMessage
+
+// UnmarshalWithUnknowns parses the JSON-encoded data and stores the result
+// in the value pointed to by v, like Unmarshal does. It also returns every
+// key in the whole document that matches no field, at any depth and within
+// array elements, so that they can be kept for types that cannot embed
+// partialmarshal.Extra.
+func UnmarshalWithUnknowns(data []byte, v interface{}) (Unknowns, error) {
+	err := Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+	unknowns := Unknowns{}
+	if reflect.TypeOf(v) == nil {
+		// Unmarshal accepts JSON values other than objects and arrays for a
+		// nil v, whose keys are all unknown to no type
+		return unknowns, nil
+	}
+	collectUnknowns(data, reflect.TypeOf(v), []string{}, unknowns)
+	return unknowns, nil
+}
+
+// MarshalWithUnknowns returns the JSON encoding of v, like Marshal does,
+// with the unknown keys put back into the nested objects and array elements
+// that their JSON Pointers refer to. Unknown keys whose parent object is not
+// part of the encoding of v are left out.
+func MarshalWithUnknowns(v interface{}, unknowns Unknowns) ([]byte, error) {
+	document, err := marshalDocument(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Put back the shallow keys first, so that deeper keys may be nested in them
+	pointers := make([][]string, 0, len(unknowns))
+	for pointer := range unknowns {
+		tokens, err := parsePointer(pointer)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) > 0 {
+			pointers = append(pointers, tokens)
+		}
+	}
+	sort.Slice(pointers, func(i, j int) bool {
+		if len(pointers[i]) != len(pointers[j]) {
+			return len(pointers[i]) < len(pointers[j])
+		}
+		return formatPointer(pointers[i]) < formatPointer(pointers[j])
+	})
+
+	for _, tokens := range pointers {
+		parent, err := getPointer(document, tokens[:len(tokens)-1])
+		if err != nil {
+			continue
+		}
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, err := decodeDocument(unknowns[formatPointer(tokens)])
+		if err != nil {
+			return nil, err
+		}
+		object[tokens[len(tokens)-1]] = value
+	}
+	return json.Marshal(document)
+}
+
+// collectUnknowns walks the JSON-encoded data along with the type it was
+// decoded into and adds the keys that match no field to unknowns.
+func collectUnknowns(data json.RawMessage, t reflect.Type, path []string, unknowns Unknowns) {
+	walkObjects(data, t, path, func(t reflect.Type, rawMap map[string]json.RawMessage, path []string) {
+		// Intermediate objects of nested keys are put back key by key, since
+		// Marshal writes them too
+		visitRemaining(rawMap, intermediatePaths(t, pathNode{}), path, func(keyPath []string, rawValue json.RawMessage) {
+			unknowns[formatPointer(keyPath)] = rawValue
+		})
+	})
+}
+
+// walkObjects walks the JSON-encoded data along with the type it is decoded
+// into and calls visit for each JSON object decoded into a struct, with the
+// keys of the object that match no field or extension of the struct type t.
+func walkObjects(data json.RawMessage, t reflect.Type, path []string, visit func(t reflect.Type, rawMap map[string]json.RawMessage, path []string)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(optionalValueType) || t.Implements(documentType) {
+		walkObjects(data, t.Field(0).Type, path, visit)
+		return
+	}
+	if hasVariants(t) {
+		if concrete, rawValue, err := selectVariant(data, t); err == nil {
+			walkObjects(rawValue, concrete, path, visit)
+		}
+		return
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) && !(t.Kind() == reflect.Struct && hasExtraStorage(t)) {
+		// Types that decode themselves decide which keys they know, unless
+		// their methods were generated for a struct that embeds Extra
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var rawMap map[string]json.RawMessage
+		if !isJSONObject(data) || json.Unmarshal(data, &rawMap) != nil || upcast(rawMap, t) != nil {
+			return
+		}
+		popFields(rawMap, t, func(field reflect.StructField, fieldPath []string, rawValue json.RawMessage) {
+			walkObjects(rawValue, field.Type, append(path[:len(path):len(path)], fieldPath...), visit)
+		})
+		popExtensions(rawMap, t, func(key string, extensionType reflect.Type, rawValue json.RawMessage) error {
+			walkObjects(rawValue, extensionType, append(path[:len(path):len(path)], key), visit)
+			return nil
+		})
+		visit(t, rawMap, path)
+	case reflect.Map:
+		var rawMap map[string]json.RawMessage
+		if !isJSONObject(data) || json.Unmarshal(data, &rawMap) != nil {
+			return
+		}
+		for key, rawValue := range rawMap {
+			walkObjects(rawValue, t.Elem(), append(path[:len(path):len(path)], key), visit)
+		}
+	case reflect.Slice, reflect.Array:
+		var rawList []json.RawMessage
+		if json.Unmarshal(data, &rawList) != nil {
+			return
+		}
+		for i, rawValue := range rawList {
+			walkObjects(rawValue, t.Elem(), append(path[:len(path):len(path)], strconv.Itoa(i)), visit)
+		}
+	}
+}
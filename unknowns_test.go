@@ -0,0 +1,144 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleUnmarshalWithUnknowns() {
+	// Struct types that cannot embed partialmarshal.Extra
+	type address struct {
+		City string `json:"city"`
+	}
+	type thirdpartystruct struct {
+		Name    string  `json:"name"`
+		Address address `json:"address"`
+	}
+
+	var destination thirdpartystruct
+	unknowns, err := UnmarshalWithUnknowns([]byte(`{
+		"name": "gopher",
+		"age": 25,
+		"address": {"city": "Gopher City", "zip": "12345"}
+	}`), &destination)
+	fmt.Println(err)
+	fmt.Printf("%s %s\n", unknowns["/age"], unknowns["/address/zip"])
+
+	destination.Name = "gopher2"
+	JSONData, _ := MarshalWithUnknowns(destination, unknowns)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// <nil>
+	// 25 "12345"
+	// {"address":{"city":"Gopher City","zip":"12345"},"age":25,"name":"gopher2"}
+}
+
+func TestUnmarshalWithUnknowns(t *testing.T) {
+	type subStruct struct {
+		SubFieldOne string `json:"sub_field_one"`
+	}
+	type testStruct struct {
+		FieldOne        string               `json:"field_one"`
+		FieldSubStruct  *subStruct           `json:"field_sub_struct"`
+		FieldSubStructs []subStruct          `json:"field_sub_structs"`
+		FieldMap        map[string]subStruct `json:"field_map"`
+		FieldRaw        map[string]string    `json:"field_raw"`
+	}
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outUnknowns     Unknowns
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should return no unknowns when every key matches",
+			[]byte(`{"field_one": "one", "field_raw": {"a": "b"}}`),
+			Unknowns{},
+			`{"field_one":"one","field_raw":{"a":"b"},"field_sub_struct":null,"field_sub_structs":null,"field_map":null}`,
+			"",
+		},
+		{
+			"should return unknown keys at every depth",
+			[]byte(`{
+				"field_one": "one",
+				"field_two": {"a": 1},
+				"field_sub_struct": {"sub_field_one": "sub one", "sub_field_two": 2},
+				"field_map": {"key": {"sub_field_three": 3}}
+			}`),
+			Unknowns{
+				"/field_two":                      []byte(`{"a": 1}`),
+				"/field_sub_struct/sub_field_two": []byte(`2`),
+				"/field_map/key/sub_field_three":  []byte(`3`),
+			},
+			`{"field_one":"one","field_two":{"a":1},"field_sub_struct":{"sub_field_one":"sub one","sub_field_two":2},"field_sub_structs":null,"field_map":{"key":{"sub_field_one":"","sub_field_three":3}},"field_raw":null}`,
+			"",
+		},
+		{
+			"should return unknown keys of array elements",
+			[]byte(`{"field_sub_structs": [{"sub_field_one": "one"}, {"sub_field_one": "two", "a/b": true}]}`),
+			Unknowns{
+				"/field_sub_structs/1/a~1b": []byte(`true`),
+			},
+			`{"field_one":"","field_sub_struct":null,"field_sub_structs":[{"sub_field_one":"one"},{"sub_field_one":"two","a/b":true}],"field_map":null,"field_raw":null}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error when provided with malformed JSON",
+			[]byte(`{decidedly not json in format`),
+			nil,
+			``,
+			"invalid character 'd' looking for beginning of object key string",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			unknowns, err := UnmarshalWithUnknowns(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outUnknowns, unknowns)
+			result, err := MarshalWithUnknowns(value, unknowns)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestUnmarshalWithUnknownsNil(t *testing.T) {
+	// Like Unmarshal, a nil value accepts JSON values other than objects
+	unknowns, err := UnmarshalWithUnknowns([]byte(`1`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Unknowns{}, unknowns)
+}
+
+func TestMarshalWithUnknowns(t *testing.T) {
+	type testStruct struct {
+		FieldOne []string `json:"field_one"`
+	}
+	unknowns := Unknowns{
+		"/field_two":          []byte(`{"a": 1}`),
+		"/field_two/b":        []byte(`2`),
+		"/field_one/0/a":      []byte(`1`),
+		"/field_three/c/d":    []byte(`3`),
+		"/field_one/5/a":      []byte(`1`),
+		"/field_one_and_more": []byte(`[]`),
+	}
+
+	// Keys whose parent object does not exist are left out
+	result, err := MarshalWithUnknowns(testStruct{[]string{"one"}}, unknowns)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"field_one":["one"],"field_two":{"a":1,"b":2},"field_one_and_more":[]}`, string(result))
+
+	_, err = MarshalWithUnknowns(testStruct{}, Unknowns{"field_two": []byte(`1`)})
+	assert.EqualError(t, err, `invalid JSON pointer "field_two"`)
+}
@@ -0,0 +1,143 @@
+package partialmarshal
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// DiscriminatorKey is the key of the JSON object of a variant whose value
+// selects its concrete type.
+const DiscriminatorKey = "type"
+
+var (
+	variantMutex  sync.RWMutex
+	variantTypes  = map[reflect.Type]map[string]reflect.Type{} // concrete types by interface type and discriminator value
+	variantValues = map[reflect.Type]string{}                  // discriminator values by struct type
+)
+
+// RegisterVariant registers concreteType, a struct or a pointer to a struct,
+// as the variant of the interface type pointed to by iface whose JSON
+// objects have the discriminatorValue under DiscriminatorKey:
+//
+//	partialmarshal.RegisterVariant((*Event)(nil), "created", Created{})
+//
+// Unmarshal then decodes interface fields, slice elements and top-level
+// values of the interface type into the concrete type selected by their
+// discriminator, keeping the extra payload of the concrete type, and Marshal
+// writes the discriminator of the values of registered concrete types. The
+// discriminator is not kept in Extra, unless the concrete type has a field
+// for it. A struct type that only implements the interface through its
+// pointer type is decoded as a pointer.
+func RegisterVariant(iface interface{}, discriminatorValue string, concreteType interface{}) error {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("partialmarshal: cannot register a variant of %v, which is not a pointer to an interface", t)
+	}
+	t = t.Elem()
+	concrete := reflect.TypeOf(concreteType)
+	if concrete == nil || inlineType(concrete) == nil {
+		return fmt.Errorf("partialmarshal: cannot register %v as a variant of %v, which is not a struct", concrete, t)
+	}
+	if !concrete.Implements(t) && concrete.Kind() == reflect.Struct && reflect.PtrTo(concrete).Implements(t) {
+		concrete = reflect.PtrTo(concrete)
+	}
+	if !concrete.Implements(t) {
+		return fmt.Errorf("partialmarshal: cannot register %v as a variant of %v, which it does not implement", concrete, t)
+	}
+
+	variantMutex.Lock()
+	defer variantMutex.Unlock()
+	structType := inlineType(concrete)
+	if value, found := variantValues[structType]; found && value != discriminatorValue {
+		return fmt.Errorf("partialmarshal: %v is already registered as variant %q", structType, value)
+	}
+	if registered, found := variantTypes[t][discriminatorValue]; found && registered != concrete {
+		return fmt.Errorf("partialmarshal: variant %q of %v is already registered as %v", discriminatorValue, t, registered)
+	}
+	if variantTypes[t] == nil {
+		variantTypes[t] = map[string]reflect.Type{}
+	}
+	variantTypes[t][discriminatorValue] = concrete
+	variantValues[structType] = discriminatorValue
+	forgetPartialTypes()
+	return nil
+}
+
+// hasVariants reports whether variants of the interface type t are registered.
+func hasVariants(t reflect.Type) bool {
+	if t.Kind() != reflect.Interface {
+		return false
+	}
+	variantMutex.RLock()
+	defer variantMutex.RUnlock()
+	return len(variantTypes[t]) > 0
+}
+
+// variantValue returns the discriminator value of the struct type t, when it
+// is registered as a variant.
+func variantValue(t reflect.Type) (string, bool) {
+	variantMutex.RLock()
+	defer variantMutex.RUnlock()
+	value, found := variantValues[t]
+	return value, found
+}
+
+// selectVariant returns the concrete type of the variant of the interface
+// type t that rawValue is the JSON object of, along with rawValue without
+// its discriminator when the concrete type has no field for it.
+func selectVariant(rawValue json.RawMessage, t reflect.Type) (reflect.Type, json.RawMessage, error) {
+	// 1. Read the discriminator of the JSON object
+	var rawMap map[string]json.RawMessage
+	if !isJSONObject(rawValue) || json.Unmarshal(rawValue, &rawMap) != nil {
+		return nil, nil, fmt.Errorf("partialmarshal: cannot decode a variant of %v from a JSON %s", t, kindOf(rawValue))
+	}
+	rawDiscriminator, found := rawMap[DiscriminatorKey]
+	if !found {
+		return nil, nil, fmt.Errorf("partialmarshal: missing discriminator %q of %v", DiscriminatorKey, t)
+	}
+	var discriminator string
+	if json.Unmarshal(rawDiscriminator, &discriminator) != nil {
+		return nil, nil, fmt.Errorf("partialmarshal: invalid discriminator %s of %v", rawDiscriminator, t)
+	}
+
+	// 2. Look up the concrete type it selects
+	variantMutex.RLock()
+	concrete, found := variantTypes[t][discriminator]
+	variantMutex.RUnlock()
+	if !found {
+		return nil, nil, fmt.Errorf("partialmarshal: unknown variant %q of %v", discriminator, t)
+	}
+
+	// 3. Leave the discriminator out of the extra payload of the concrete type
+	if _, isField := fieldByJSONName(inlineType(concrete), DiscriminatorKey); !isField {
+		delete(rawMap, DiscriminatorKey)
+		stripped, err := json.Marshal(rawMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawValue = stripped
+	}
+	return concrete, rawValue, nil
+}
+
+// decodeVariant decodes rawValue into a new value of the concrete type of
+// the variant of the interface type t that it selects. A JSON null decodes
+// into a nil interface.
+//
+// The value is also returned along with a *RequiredError.
+func decodeVariant(rawValue json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	if isJSONNull(rawValue) {
+		return reflect.Zero(t), nil
+	}
+	concrete, rawValue, err := selectVariant(rawValue, t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	value, err := decodeValue(rawValue, concrete)
+	if err != nil && asRequiredError(err) == nil {
+		return reflect.Value{}, err
+	}
+	return value.Convert(t), err
+}
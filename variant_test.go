@@ -0,0 +1,232 @@
+package partialmarshal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEvent interface {
+	eventName() string
+}
+
+type testCreated struct {
+	ID string `json:"id" partialmarshal:"required"`
+	Extra
+}
+
+func (testCreated) eventName() string { return "created" }
+
+type testDeleted struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (*testDeleted) eventName() string { return "deleted" }
+
+type testRenamed struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func (testRenamed) eventName() string { return "renamed" }
+
+type testArchived struct{}
+
+func (testArchived) eventName() string { return "archived" }
+
+func init() {
+	RegisterVariant((*testEvent)(nil), "created", testCreated{})
+	RegisterVariant((*testEvent)(nil), "deleted", testDeleted{})
+	RegisterVariant((*testEvent)(nil), "renamed", testRenamed{})
+}
+
+func ExampleRegisterVariant() {
+	var events []testEvent
+	Unmarshal([]byte(`[{"type": "created", "id": "a", "by": "gopher"}, {"type": "deleted", "id": "a"}]`), &events)
+	for _, event := range events {
+		fmt.Printf("%T\n", event)
+	}
+
+	JSONData, _ := Marshal(events)
+	fmt.Println(string(JSONData))
+
+	// Output:
+	// partialmarshal.testCreated
+	// *partialmarshal.testDeleted
+	// [{"by":"gopher","id":"a","type":"created"},{"id":"a","reason":"","type":"deleted"}]
+}
+
+func TestRegisterVariant(t *testing.T) {
+	testCases := []struct {
+		testDescription string
+		inInterface     interface{}
+		inValue         string
+		inConcrete      interface{}
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should register a variant again",
+			(*testEvent)(nil),
+			"created",
+			testCreated{},
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for a value that is not a pointer to an interface",
+			testCreated{},
+			"created",
+			testCreated{},
+			"partialmarshal: cannot register a variant of partialmarshal.testCreated, which is not a pointer to an interface",
+		},
+		{
+			"should return error for a concrete type that is not a struct",
+			(*testEvent)(nil),
+			"text",
+			"",
+			"partialmarshal: cannot register string as a variant of partialmarshal.testEvent, which is not a struct",
+		},
+		{
+			"should return error for a concrete type that does not implement the interface",
+			(*testEvent)(nil),
+			"other",
+			Document[int]{},
+			"partialmarshal: cannot register partialmarshal.Document[int] as a variant of partialmarshal.testEvent, which it does not implement",
+		},
+		{
+			"should return error for a concrete type registered under another value",
+			(*testEvent)(nil),
+			"made",
+			testCreated{},
+			`partialmarshal: partialmarshal.testCreated is already registered as variant "created"`,
+		},
+		{
+			"should return error for a value registered for another concrete type",
+			(*testEvent)(nil),
+			"created",
+			testArchived{},
+			`partialmarshal: variant "created" of partialmarshal.testEvent is already registered as partialmarshal.testCreated`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			err := RegisterVariant(tc.inInterface, tc.inValue, tc.inConcrete)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUnmarshalVariants(t *testing.T) {
+	type testStruct struct {
+		Event  testEvent   `json:"event"`
+		Events []testEvent `json:"events"`
+		Extra
+	}
+
+	testCases := []struct {
+		testDescription string
+		inData          []byte
+		outValue        testStruct
+		outData         string
+		outErrMsg       string
+	}{
+		// Happy Path Cases
+		{
+			"should decode interface fields and slice elements into their variant",
+			[]byte(`{"event": {"type": "created", "id": "a", "by": "gopher"}, "events": [{"type": "deleted", "id": "b"}, {"type": "renamed", "name": "c"}, null]}`),
+			testStruct{
+				Event:  testCreated{ID: "a", Extra: Extra{"by": []byte(`"gopher"`)}},
+				Events: []testEvent{&testDeleted{ID: "b"}, testRenamed{Type: "renamed", Name: "c"}, nil},
+				Extra:  Extra{},
+			},
+			`{"event":{"type":"created","id":"a","by":"gopher"},"events":[{"type":"deleted","id":"b","reason":""},{"type":"renamed","name":"c"},null]}`,
+			"",
+		},
+		{
+			"should decode null into a nil interface",
+			[]byte(`{"event": null, "events": null}`),
+			testStruct{Extra: Extra{}},
+			`{"event":null,"events":null}`,
+			"",
+		},
+		// Sad Path Cases
+		{
+			"should return error for missing required keys of variants",
+			[]byte(`{"events": [{"type": "created"}]}`),
+			testStruct{},
+			``,
+			`partialmarshal: missing required keys: "/events/0/id"`,
+		},
+		{
+			"should return error for unknown variants",
+			[]byte(`{"event": {"type": "updated"}}`),
+			testStruct{},
+			``,
+			`partialmarshal: unknown variant "updated" of partialmarshal.testEvent`,
+		},
+		{
+			"should return error for a missing discriminator",
+			[]byte(`{"event": {"id": "a"}}`),
+			testStruct{},
+			``,
+			`partialmarshal: missing discriminator "type" of partialmarshal.testEvent`,
+		},
+		{
+			"should return error for a discriminator that is not a string",
+			[]byte(`{"event": {"type": 1}}`),
+			testStruct{},
+			``,
+			`partialmarshal: invalid discriminator 1 of partialmarshal.testEvent`,
+		},
+		{
+			"should return error for values that are not objects",
+			[]byte(`{"event": "created"}`),
+			testStruct{},
+			``,
+			`partialmarshal: cannot decode a variant of partialmarshal.testEvent from a JSON string`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testDescription, func(t *testing.T) {
+			var value testStruct
+			err := Unmarshal(tc.inData, &value)
+			if tc.outErrMsg != "" {
+				assert.EqualError(t, err, tc.outErrMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.outValue, value)
+			result, err := Marshal(value)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.outData, string(result))
+		})
+	}
+}
+
+func TestUnmarshalTopLevelVariant(t *testing.T) {
+	var event testEvent
+	err := Unmarshal([]byte(`{"type": "deleted", "id": "a", "reason": "spam"}`), &event)
+	assert.NoError(t, err)
+	assert.Equal(t, &testDeleted{ID: "a", Reason: "spam"}, event)
+
+	event, err = UnmarshalAs[testEvent]([]byte(`{"type": "created", "id": "b"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, testCreated{ID: "b", Extra: Extra{}}, event)
+	result, err := Marshal(event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"created","id":"b"}`, string(result))
+
+	// The discriminator is not an unknown key
+	unknowns, err := UnmarshalWithUnknowns([]byte(`{"type": "deleted", "id": "a", "by": "gopher"}`), &event)
+	assert.NoError(t, err)
+	assert.Equal(t, Unknowns{"/by": []byte(`"gopher"`)}, unknowns)
+}